@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Force baselines the database even if migrations were already recorded (`--force`).
+const Force = "force"
+
+// Mark the migrations up to a revision as applied, without running them.
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Mark the migrations up to a revision as applied, without running them",
+	Long: `
+The baseline command adopts an existing database, built by hand or with 
+another tool.  Every migration up to and including the --revision is marked
+as applied without running it, and its "down" SQL is stored in the database
+so it can be rolled back later.
+
+The baseline command refuses to run if migrations were already recorded in
+the database, unless --force is given.
+
+For example:
+
+    $ migrate baseline --uri=postgres://localhost/myapp_db --revision=12
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		revision := viper.GetInt64(Revision)
+		if revision < 0 {
+			migrations.Log.Infof("The --%s to baseline the database at is required", Revision)
+			os.Exit(1)
+		}
+
+		conn, err := connect()
+		if err != nil {
+			migrations.Log.Infof("Unable to connect to the database: %s", err)
+			os.Exit(1)
+		}
+
+		migrator := migrations.NewMigrator(options())
+
+		if viper.GetBool(Force) {
+			err = migrator.ForceBaselineContext(cmd.Context(), conn, revision)
+		} else {
+			err = migrator.BaselineContext(cmd.Context(), conn, revision)
+		}
+
+		if err != nil {
+			migrations.Log.Infof("Unable to baseline the database: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	baselineCmd.Flags().Bool(Force, false, "baseline even if migrations were already recorded")
+	_ = viper.BindPFlag(Force, baselineCmd.Flags().Lookup(Force))
+
+	root.AddCommand(baselineCmd)
+}
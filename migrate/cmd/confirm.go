@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Environments tagged as production, which require confirmation before repairing.
+var production = map[string]bool{
+	"prod":       true,
+	"production": true,
+}
+
+// Asks the user to confirm the change if the `--env` is tagged as production.  Returns true if the
+// change may proceed.
+func confirm(change string) bool {
+	env := strings.ToLower(strings.TrimSpace(viper.GetString(Environment)))
+	if !production[env] || viper.GetBool(Yes) {
+		return true
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "About to %s in the %s environment.  Type \"yes\" to continue: ", change, env)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	return strings.TrimSpace(strings.ToLower(answer)) == "yes"
+}
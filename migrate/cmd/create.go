@@ -7,7 +7,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
-	"github.com/sbowman/migrations"
+	"github.com/sbowman/migrations/v2"
 )
 
 // Create a migration file in the local directory.
@@ -17,17 +17,25 @@ var createCmd = &cobra.Command{
 	Long: `
 The create command generates new SQL migration files in the migrations 
 directory (./sql by default).  It will automatically generate the next 
-version number for you.  
+version number for you.  With --timestamp, the version is the current UTC
+time instead, e.g. 20261017153000-create-users.sql, which avoids collisions
+when several branches add migrations at once.
 
 For example:
 
     $ migrate create create-users
+    $ migrate create --timestamp create-users
     
 `,
 
 	Run: func(cmd *cobra.Command, args []string) {
+		options := migrations.WithDirectory(viper.GetString(Migrations))
+		if viper.GetBool(Timestamp) {
+			options = options.WithTimestamps()
+		}
+
 		for _, arg := range args {
-			if err := migrations.Create(viper.GetString(Migrations), arg); err != nil {
+			if err := options.Create(arg); err != nil {
 				_, _ = fmt.Fprintf(os.Stderr, "Unable to create migration %s: %s", arg, err)
 				os.Exit(1)
 			}
@@ -35,6 +43,12 @@ For example:
 	},
 }
 
+// Timestamp names the new migrations with a timestamp revision (`--timestamp`).
+const Timestamp = "timestamp"
+
 func init() {
+	createCmd.Flags().Bool(Timestamp, false, "use the current UTC time as the revision")
+	_ = viper.BindPFlag(Timestamp, createCmd.Flags().Lookup(Timestamp))
+
 	root.AddCommand(createCmd)
 }
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+)
+
+// Record the database as being at a revision without running any migrations.
+var forceCmd = &cobra.Command{
+	Use:   "force <revision>",
+	Short: "Record the database as being at a revision, without running any migrations",
+	Args:  cobra.ExactArgs(1),
+	Long: `
+The force command records the database as being at the revision, without 
+running any migrations.  The migrations up to and including the revision 
+are marked as applied, any later migrations are unmarked, and any dirty 
+migrations are cleared.
+
+With --env=production, the command asks for confirmation first; use --yes 
+to skip it.
+
+For example:
+
+    $ migrate force 12 --uri=postgres://localhost/myapp_db
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		revision, err := revisionArg(args)
+		if err != nil {
+			migrations.Log.Infof(err.Error())
+			os.Exit(1)
+		}
+
+		if !confirm(fmt.Sprintf("force the database to revision %d", revision)) {
+			migrations.Log.Infof("Cancelled")
+			os.Exit(1)
+		}
+
+		conn, err := connect()
+		if err != nil {
+			migrations.Log.Infof("Unable to connect to the database: %s", err)
+			os.Exit(1)
+		}
+
+		if err := migrations.NewMigrator(options()).ForceRevisionContext(cmd.Context(), conn, revision); err != nil {
+			migrations.Log.Infof("Unable to force the database to revision %d: %s", revision, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	root.AddCommand(forceCmd)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// HistoryMigration shows only the history of one migration file (`--migration`).
+	HistoryMigration = "migration"
+
+	// Limit is the number of most recent events to show (`--limit`).
+	Limit = "limit"
+)
+
+// Show the history of the migrations applied to and rolled back from the database.
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the history of migrations applied, rolled back, failed, or repaired",
+	Long: `
+The history command lists the events recorded in the migrations history, 
+oldest first:  each migration applied, rolled back, baselined or repaired, 
+and each failure, along with who ran it and how long it took.  Unlike the
+status command, the history includes migrations that were rolled back.
+
+For example:
+
+    $ migrate history --uri=postgres://localhost/myapp_db --limit=20
+    $ migrate history --uri=postgres://localhost/myapp_db --migration=12-add-users.sql
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printHistory(cmd.Context(), cmd); err != nil {
+			migrations.Log.Infof("Unable to get the migrations history: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// Output the migrations history to stdout, as a table or JSON.  The flags are read from the
+// command rather than viper, as `--json` is shared with the status command.
+func printHistory(ctx context.Context, cmd *cobra.Command) error {
+	conn, err := connect()
+	if err != nil {
+		return err
+	}
+
+	var filter migrations.HistoryFilter
+	filter.Migration, _ = cmd.Flags().GetString(HistoryMigration)
+	filter.Limit, _ = cmd.Flags().GetInt(Limit)
+
+	history, err := migrations.NewMigrator(options()).HistoryContext(ctx, conn, filter)
+	if err != nil {
+		return err
+	}
+
+	if asJSON, _ := cmd.Flags().GetBool(JSON); asJSON {
+		if history == nil {
+			history = []migrations.HistoryRecord{}
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(history)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "RECORDED AT\tEVENT\tMIGRATION\tDIRECTION\tDURATION\tUSER\tACTOR\tERROR")
+
+	for _, record := range history {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", record.RecordedAt.Local().Format(time.RFC3339),
+			record.Event, record.Migration, record.Direction, record.Duration, record.DBUser, record.Actor,
+			record.Error)
+	}
+
+	return w.Flush()
+}
+
+func init() {
+	historyCmd.Flags().String(HistoryMigration, "", "only show the history of this migration file")
+	historyCmd.Flags().Int(Limit, 50, "show only the most recent events; 0 shows them all")
+	historyCmd.Flags().Bool(JSON, false, "output the history as JSON")
+
+	root.AddCommand(historyCmd)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+)
+
+// Check the migration files for risky PostgreSQL operations.
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check the migration files for risky PostgreSQL operations",
+	Long: `
+The lint command checks the migration files for operations that are risky
+to run against a live database, such as creating an index without
+"concurrently" or adding a "not null" column without a default.  Each
+finding is reported as "file:line: rule: message", and the command exits
+with a non-zero status if there are any, so it may be run in CI.  It
+doesn't connect to the database.
+
+To suppress a finding, add a "-- lint:ignore <rule> [reason]" comment on
+the line above the statement, or "-- lint:file-ignore <rule> [reason]" to
+suppress the rule for the whole file.
+
+For example:
+
+    $ migrate lint --migrations=./sql
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		findings, err := migrations.Lint(options())
+		if err != nil {
+			migrations.Log.Infof("Unable to lint the migrations: %s", err)
+			os.Exit(1)
+		}
+
+		for _, finding := range findings {
+			fmt.Println(finding)
+		}
+
+		if len(findings) > 0 {
+			migrations.Log.Infof("Found %d problem(s) in the migrations", len(findings))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	root.AddCommand(lintCmd)
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+)
+
+// Record a migration as applied without running it.
+var markAppliedCmd = &cobra.Command{
+	Use:   "mark-applied <revision>",
+	Short: "Record a migration as applied, without running it",
+	Args:  cobra.ExactArgs(1),
+	Long: `
+The mark-applied command records the migration with the revision as applied
+without running it, and stores its "down" SQL in the database.  Use it after
+applying a migration by hand, or once a failed /notx migration has been 
+finished by hand, which also clears the dirty flag.
+
+With --env=production, the command asks for confirmation first; use --yes 
+to skip it.
+
+For example:
+
+    $ migrate mark-applied 12 --uri=postgres://localhost/myapp_db
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		revision, err := revisionArg(args)
+		if err != nil {
+			migrations.Log.Infof(err.Error())
+			os.Exit(1)
+		}
+
+		if !confirm(fmt.Sprintf("mark revision %d as applied", revision)) {
+			migrations.Log.Infof("Cancelled")
+			os.Exit(1)
+		}
+
+		conn, err := connect()
+		if err != nil {
+			migrations.Log.Infof("Unable to connect to the database: %s", err)
+			os.Exit(1)
+		}
+
+		if err := migrations.NewMigrator(options()).MarkAppliedContext(cmd.Context(), conn, revision); err != nil {
+			migrations.Log.Infof("Unable to mark revision %d as applied: %s", revision, err)
+			os.Exit(1)
+		}
+	},
+}
+
+// Parses the revision from the command-line arguments.
+func revisionArg(args []string) (int64, error) {
+	revision, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || revision < 0 {
+		return 0, fmt.Errorf("invalid revision %q", args[0])
+	}
+
+	return revision, nil
+}
+
+func init() {
+	root.AddCommand(markAppliedCmd)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Report the migrations that would be applied.
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show the migrations that would be applied, without applying them",
+	Long: `
+The plan command reports each step the migrations would take to bring the 
+database to the requested revision, including the SQL that would be run, 
+without changing the database.  Same as "migrate --dry-run".
+
+For example:
+
+    $ migrate plan --uri=postgres://localhost/myapp_db --revision=12
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printPlan(cmd.Context()); err != nil {
+			migrations.Log.Infof("Unable to plan the migrations: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// Output the planned migration steps to stdout.
+func printPlan(ctx context.Context) error {
+	conn, err := connect()
+	if err != nil {
+		return err
+	}
+
+	steps, err := options().WithRevision(viper.GetInt64(Revision)).PlanContext(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if len(steps) == 0 {
+		fmt.Println("Nothing to migrate")
+		return nil
+	}
+
+	for _, step := range steps {
+		fmt.Println(step)
+
+		SQL := strings.TrimSpace(string(step.SQL))
+		if SQL != "" {
+			fmt.Println()
+			fmt.Println(SQL)
+			fmt.Println()
+		}
+	}
+
+	return nil
+}
+
+func init() {
+	root.AddCommand(planCmd)
+}
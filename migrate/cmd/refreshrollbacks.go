@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+)
+
+// Replace the stored rollbacks that no longer match the migration files.
+var refreshRollbacksCmd = &cobra.Command{
+	Use:   "refresh-rollbacks",
+	Short: "Update the stored rollbacks to match the Down section of the migration files",
+	Long: `
+The refresh-rollbacks command compares the "down" SQL stored in
+migrations.rollbacks against the Down section of each applied migration
+file, shows a diff of every stored rollback that's out of sync, and
+replaces them with the SQL from the files.  Use this after fixing a buggy
+rollback in a migration that has already been applied.
+
+With --dry-run, the diffs are shown but nothing is changed.  With
+--env=production, the command asks for confirmation first; use --yes to
+skip it.
+
+For example:
+
+    $ migrate refresh-rollbacks --dry-run --uri=postgres://localhost/myapp_db
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		conn, err := connect()
+		if err != nil {
+			migrations.Log.Infof("Unable to connect to the database: %s", err)
+			os.Exit(1)
+		}
+
+		migrator := migrations.NewMigrator(options())
+
+		stale, err := migrator.StaleRollbacksContext(cmd.Context(), conn)
+		if err != nil {
+			migrations.Log.Infof("Unable to compare the stored rollbacks: %s", err)
+			os.Exit(1)
+		}
+
+		if len(stale) == 0 {
+			migrations.Log.Infof("The stored rollbacks are up to date")
+			return
+		}
+
+		for _, drift := range stale {
+			fmt.Printf("--- %s\n%s\n", drift.Migration, drift.Diff())
+		}
+
+		if dryRun, _ := cmd.Flags().GetBool(DryRun); dryRun {
+			return
+		}
+
+		if !confirm(fmt.Sprintf("refresh %d stored rollback(s)", len(stale))) {
+			migrations.Log.Infof("Cancelled")
+			os.Exit(1)
+		}
+
+		refreshed, err := migrator.RefreshStaleRollbacksContext(cmd.Context(), conn)
+		if err != nil {
+			migrations.Log.Infof("Unable to refresh the stored rollbacks: %s", err)
+			os.Exit(1)
+		}
+
+		migrations.Log.Infof("Refreshed %d stored rollback(s)", len(refreshed))
+	},
+}
+
+func init() {
+	refreshRollbacksCmd.Flags().Bool(DryRun, false, "show the stored rollbacks that would be refreshed, without changing them")
+
+	root.AddCommand(refreshRollbacksCmd)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+)
+
+// Fix duplicate revision numbers after merging branches.
+var renumberCmd = &cobra.Command{
+	Use:   "renumber",
+	Short: "Renumber migration files that share a revision number",
+	Long: `
+The renumber command fixes migration files that share a revision number, 
+typically after merging branches.  For each duplicate revision, the file 
+already applied to the database keeps its number, and the others are renamed 
+to follow the latest migration.  If none of them have been applied, the first 
+file by name keeps its number.  Fails if more than one of the colliding files 
+has already been applied.
+
+For example:
+
+    $ migrate renumber --uri=postgres://localhost/myapp_db --migrations=./sql
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		conn, err := connect()
+		if err != nil {
+			migrations.Log.Infof("Unable to connect to the database: %s", err)
+			os.Exit(1)
+		}
+
+		renamed, err := migrations.NewMigrator(options()).RenumberContext(cmd.Context(), conn)
+		if err != nil {
+			migrations.Log.Infof("Unable to renumber the migrations: %s", err)
+			os.Exit(1)
+		}
+
+		if len(renamed) == 0 {
+			migrations.Log.Infof("No duplicate revisions found")
+		}
+	},
+}
+
+func init() {
+	root.AddCommand(renumberCmd)
+}
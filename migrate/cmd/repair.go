@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+)
+
+// Re-stamp the checksums of modified migration files.
+var repairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Record the checksums of modified migration files",
+	Long: `
+The repair command records the current checksum of every applied migration
+file, so modified files no longer fail verification.  Only run this once 
+the changes to the files have been reviewed.
+
+For example:
+
+    $ migrate repair --uri=postgres://localhost/myapp_db --migrations=./sql
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		conn, err := connect()
+		if err != nil {
+			migrations.Log.Infof("Unable to connect to the database: %s", err)
+			os.Exit(1)
+		}
+
+		updated, err := migrations.NewMigrator(options()).RepairChecksums(conn)
+		if err != nil {
+			migrations.Log.Infof("Unable to repair the checksums: %s", err)
+			os.Exit(1)
+		}
+
+		migrations.Log.Infof("Updated %d checksum(s)", updated)
+	},
+}
+
+func init() {
+	root.AddCommand(repairCmd)
+}
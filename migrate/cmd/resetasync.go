@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+)
+
+// Clear the state of an asynchronous migration abandoned by a crashed process.
+var resetAsyncCmd = &cobra.Command{
+	Use:   "reset-async <revision>",
+	Short: "Retry an asynchronous migration left queued or running",
+	Args:  cobra.ExactArgs(1),
+	Long: `
+The reset-async command clears the state of the /async migration with the 
+revision from the migrations.applied_async table, so it runs again the 
+next time the database is migrated.  Use it when a migration is left 
+queued or running because the process running it crashed.  Check the 
+migration isn't still running first.
+
+With --env=production, the command asks for confirmation first; use --yes 
+to skip it.
+
+For example:
+
+    $ migrate reset-async 12 --uri=postgres://localhost/myapp_db
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		revision, err := revisionArg(args)
+		if err != nil {
+			migrations.Log.Infof(err.Error())
+			os.Exit(1)
+		}
+
+		if !confirm(fmt.Sprintf("reset asynchronous revision %d", revision)) {
+			migrations.Log.Infof("Cancelled")
+			os.Exit(1)
+		}
+
+		conn, err := connect()
+		if err != nil {
+			migrations.Log.Infof("Unable to connect to the database: %s", err)
+			os.Exit(1)
+		}
+
+		if err := migrations.NewMigrator(options()).ResetAsyncContext(cmd.Context(), conn, revision); err != nil {
+			migrations.Log.Infof("Unable to reset revision %d: %s", revision, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	root.AddCommand(resetAsyncCmd)
+}
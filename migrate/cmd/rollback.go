@@ -3,26 +3,69 @@ package cmd
 import (
 	"os"
 
-	"github.com/sbowman/migrations"
+	"github.com/sbowman/migrations/v2"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-// Create a migration file in the local directory.
+const (
+	// Steps is the number of migrations to roll back (`--steps`).
+	Steps = "steps"
+
+	// LastBatch is the number of batches, i.e. calls to apply the migrations, to roll back
+	// (`--last-batch`).
+	LastBatch = "last-batch"
+)
+
+// Roll back the most recent migrations.
 var rollbackCmd = &cobra.Command{
 	Use:   "rollback",
-	Short: "Rollback the database to the ",
+	Short: "Roll back the most recently applied migrations",
+	Long: `
+The rollback command rolls back the most recently applied migrations.  By
+default it rolls back the last migration; use --steps to roll back more.
+
+With --last-batch, the rollback command instead rolls back every migration
+applied by the last N runs of migrate, newest first, using the "down" SQL
+stored in the database, so the migration files don't need to be present.
+
+For example:
+
+    $ migrate rollback --uri=postgres://localhost/myapp_db --steps=2
+    $ migrate rollback --uri=postgres://localhost/myapp_db --last-batch=1
+
+`,
 
 	Run: func(cmd *cobra.Command, args []string) {
-		for _, arg := range args {
-			if err := migrations.Create(viper.GetString(Migrations), arg); err != nil {
-				migrations.Log.Infof(err.Error())
+		conn, err := connect()
+		if err != nil {
+			migrations.Log.Infof("Unable to connect to the database: %s", err)
+			os.Exit(1)
+		}
+
+		migrator := migrations.NewMigrator(options())
+
+		if batches := viper.GetInt(LastBatch); batches > 0 {
+			if err := migrator.RollbackBatchContext(cmd.Context(), conn, batches); err != nil {
+				migrations.Log.Infof("Unable to roll back the last %d batch(es): %s", batches, err)
 				os.Exit(1)
 			}
+			return
+		}
+
+		if err := migrator.RollbackContext(cmd.Context(), conn, viper.GetInt(Steps)); err != nil {
+			migrations.Log.Infof("Unable to roll back the migrations: %s", err)
+			os.Exit(1)
 		}
 	},
 }
 
 func init() {
-	root.AddCommand(createCmd)
+	rollbackCmd.Flags().Int(Steps, 1, "the number of migrations to roll back")
+	rollbackCmd.Flags().Int(LastBatch, 0, "roll back the migrations applied by the last N runs of migrate")
+
+	_ = viper.BindPFlag(Steps, rollbackCmd.Flags().Lookup(Steps))
+	_ = viper.BindPFlag(LastBatch, rollbackCmd.Flags().Lookup(LastBatch))
+
+	root.AddCommand(rollbackCmd)
 }
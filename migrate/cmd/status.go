@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// JSON outputs the migrations status as JSON (`--json`).
+const JSON = "json"
+
+// Report where each migration stands in the database.
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of each migration in the database",
+	Long: `
+The status command lists every migration known to the SQL migration files or
+the database, and whether it has been applied, is pending, or was applied but
+its file is missing.  Also reports migration files modified after they were
+applied, and rollbacks stored in the database that no longer match the file.
+
+For example:
+
+    $ migrate status --uri=postgres://localhost/myapp_db --migrations=./sql
+    $ migrate status --uri=postgres://localhost/myapp_db --json
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printStatus(cmd.Context()); err != nil {
+			migrations.Log.Infof("Unable to get the migrations status: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// Output the status of the migrations to stdout, as a table or JSON.
+func printStatus(ctx context.Context) error {
+	conn, err := connect()
+	if err != nil {
+		return err
+	}
+
+	statuses, err := options().StatusContext(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if viper.GetBool(JSON) {
+		if statuses == nil {
+			statuses = []migrations.MigrationStatus{}
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(statuses)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "REVISION\tMIGRATION\tSTATE\tAPPLIED AT\tNOTES")
+
+	for _, status := range statuses {
+		var appliedAt string
+		if !status.AppliedAt.IsZero() {
+			appliedAt = status.AppliedAt.Local().Format(time.RFC3339)
+		}
+
+		var notes []string
+		if status.Modified {
+			notes = append(notes, "modified")
+		}
+
+		if status.RollbackDrift {
+			notes = append(notes, "rollback out of sync")
+		}
+
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", status.Revision, status.Migration, status.State,
+			appliedAt, strings.Join(notes, ", "))
+	}
+
+	return w.Flush()
+}
+
+func init() {
+	statusCmd.Flags().Bool(JSON, false, "output the status as JSON")
+	_ = viper.BindPFlag(JSON, statusCmd.Flags().Lookup(JSON))
+
+	root.AddCommand(statusCmd)
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Release a stale migrations lock.
+var unlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Release a stale migrations lock",
+	Long: `
+The unlock command releases the PostgreSQL advisory lock that keeps multiple
+instances from migrating the database at the same time.  Advisory locks are 
+held by a database session, so unlock terminates any sessions holding the 
+lock.  Only use this if the instance holding the lock has hung.
+
+For example:
+
+    $ migrate unlock --uri=postgres://localhost/myapp_db
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		conn, err := connect()
+		if err != nil {
+			migrations.Log.Infof("Unable to connect to the database: %s", err)
+			os.Exit(1)
+		}
+
+		terminated, err := migrations.ForceUnlock(conn, viper.GetInt64(LockKey))
+		if err != nil {
+			migrations.Log.Infof("Unable to release the migrations lock: %s", err)
+			os.Exit(1)
+		}
+
+		if terminated == 0 {
+			migrations.Log.Infof("The migrations lock is not held")
+			return
+		}
+
+		migrations.Log.Infof("Released the migrations lock; terminated %d session(s)", terminated)
+	},
+}
+
+func init() {
+	root.AddCommand(unlockCmd)
+}
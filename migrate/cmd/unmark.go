@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+)
+
+// Remove a migration from the tracking tables without rolling it back.
+var unmarkCmd = &cobra.Command{
+	Use:   "unmark <revision>",
+	Short: "Forget a migration was applied, without rolling it back",
+	Args:  cobra.ExactArgs(1),
+	Long: `
+The unmark command removes the migration with the revision from the 
+tracking tables, without rolling it back, so it runs again the next time 
+the database is migrated.  Use it after rolling back a migration by hand, 
+or once a failed /notx migration has been undone by hand.
+
+With --env=production, the command asks for confirmation first; use --yes 
+to skip it.
+
+For example:
+
+    $ migrate unmark 12 --uri=postgres://localhost/myapp_db
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		revision, err := revisionArg(args)
+		if err != nil {
+			migrations.Log.Infof(err.Error())
+			os.Exit(1)
+		}
+
+		if !confirm(fmt.Sprintf("unmark revision %d", revision)) {
+			migrations.Log.Infof("Cancelled")
+			os.Exit(1)
+		}
+
+		conn, err := connect()
+		if err != nil {
+			migrations.Log.Infof("Unable to connect to the database: %s", err)
+			os.Exit(1)
+		}
+
+		if err := migrations.NewMigrator(options()).UnmarkContext(cmd.Context(), conn, revision); err != nil {
+			migrations.Log.Infof("Unable to unmark revision %d: %s", revision, err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	root.AddCommand(unmarkCmd)
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sbowman/migrations/v2"
+	"github.com/spf13/cobra"
+)
+
+// Check the migration files haven't been modified since they were applied.
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Report migration files modified after they were applied",
+	Long: `
+The verify command compares the checksums recorded when each migration was 
+applied against the SQL migration files, and reports every file that was 
+modified.  Exits with an error if any files were modified.
+
+For example:
+
+    $ migrate verify --uri=postgres://localhost/myapp_db --migrations=./sql
+
+`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		conn, err := connect()
+		if err != nil {
+			migrations.Log.Infof("Unable to connect to the database: %s", err)
+			os.Exit(1)
+		}
+
+		mismatches, err := migrations.NewMigrator(options()).Verify(conn)
+		if err != nil {
+			migrations.Log.Infof("Unable to verify the migrations: %s", err)
+			os.Exit(1)
+		}
+
+		if len(mismatches) == 0 {
+			migrations.Log.Infof("All applied migrations match their files")
+			return
+		}
+
+		for _, mismatch := range mismatches {
+			migrations.Log.Infof("%s (expected %s, found %s)", mismatch, mismatch.Expected, mismatch.Actual)
+		}
+
+		os.Exit(1)
+	},
+}
+
+func init() {
+	root.AddCommand(verifyCmd)
+}
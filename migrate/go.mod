@@ -1,11 +1,32 @@
-module migrate
+module github.com/sbowman/migrations/migrate
 
-go 1.13
+go 1.19
 
-replace github.com/sbowman/migrations v1.5.0 => ../
+replace github.com/sbowman/migrations/v2 v2.0.0 => ../v2
 
 require (
-	github.com/jackc/pgx/v4 v4.16.1 // indirect
-	github.com/sbowman/migrations v1.5.0 // indirect
-	github.com/spf13/cobra v1.2.1 // indirect
+	github.com/jackc/pgx/v5 v5.3.0
+	github.com/sbowman/migrations/v2 v2.0.0
+	github.com/spf13/cobra v1.2.1
+	github.com/spf13/viper v1.8.1
+)
+
+require (
+	github.com/fsnotify/fsnotify v1.4.9 // indirect
+	github.com/hashicorp/hcl v1.0.0 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
+	github.com/magiconair/properties v1.8.5 // indirect
+	github.com/mitchellh/mapstructure v1.4.1 // indirect
+	github.com/pelletier/go-toml v1.9.3 // indirect
+	github.com/spf13/afero v1.6.0 // indirect
+	github.com/spf13/cast v1.3.1 // indirect
+	github.com/spf13/jwalterweatherman v1.1.0 // indirect
+	github.com/spf13/pflag v1.0.5 // indirect
+	github.com/subosito/gotenv v1.2.0 // indirect
+	golang.org/x/crypto v0.6.0 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+	golang.org/x/text v0.7.0 // indirect
+	gopkg.in/ini.v1 v1.62.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
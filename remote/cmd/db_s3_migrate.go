@@ -2,13 +2,14 @@ package cmd
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
-	"github.com/sbowman/migrations"
 	"github.com/sbowman/migrations/remote"
+	"github.com/sbowman/migrations/v2"
 )
 
 // Revision is the migration revision number setting.
@@ -20,16 +21,6 @@ var migrateS3Cmd = &cobra.Command{
 	Short: "Migrate the database",
 
 	Run: func(cmd *cobra.Command, args []string) {
-		if viper.GetString(Bucket) != "" {
-			migrations.Log.Infof("Running remote migrations in region %s, bucket %s", viper.GetString(Region), viper.GetString(Bucket))
-
-			// This is all that's required to do to run migrations from S3 buckets
-			if err := remote.InitS3(viper.GetString(Region)); err != nil {
-				migrations.Log.Infof("Unable to connect to S3: %s", err)
-				os.Exit(1)
-			}
-		}
-
 		if viper.GetInt(Revision) >= 0 {
 			migrations.Log.Infof("Migrating %s to revision %d", viper.GetString(URI), viper.GetInt(Revision))
 		} else {
@@ -38,6 +29,7 @@ var migrateS3Cmd = &cobra.Command{
 
 		if err := runMigrations(); err != nil {
 			migrations.Log.Infof("Failed to migrate: %s", err)
+			os.Exit(1)
 		}
 	},
 }
@@ -50,26 +42,25 @@ func runMigrations() error {
 
 	// Because we identify the local migrations directory (db.migrations)
 	// and the S3 bucket differently (db.bucket), we should check local vs.
-	// remote migrations...
-	location := viper.GetString(Bucket)
-	if location == "" {
-		location = viper.GetString(Migrations)
-
-		// Temporarily use disk-based migrations
-		current := migrations.IO
-		migrations.IO = new(migrations.DiskReader)
-		defer func() {
-			migrations.IO = current
-		}()
-	}
+	// remote migrations...  The reader belongs to this migrator alone, so
+	// nothing else in the process is affected.
+	options := migrations.DefaultOptions().
+		WithDirectory(viper.GetString(Migrations)).
+		WithRevision(int64(viper.GetInt(Revision)))
+
+	if bucket := viper.GetString(Bucket); bucket != "" {
+		migrations.Log.Infof("Running remote migrations in region %s, bucket %s", viper.GetString(Region), bucket)
+
+		s3r, err := remote.NewS3Reader(viper.GetString(Region))
+		if err != nil {
+			return fmt.Errorf("unable to connect to S3: %w", err)
+		}
 
-	migrations.Log.Infof("Running migrations in %s...", location)
-	if err := migrations.Migrate(conn, location, viper.GetInt(Revision)); err != nil {
-		migrations.Log.Infof(err.Error())
-		os.Exit(1)
+		options = options.WithDirectory(bucket).WithReader(s3r)
 	}
 
-	return nil
+	migrations.Log.Infof("Running migrations in %s...", options.Directory)
+	return migrations.NewMigrator(options).Apply(conn)
 }
 
 func init() {
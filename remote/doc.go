@@ -42,6 +42,23 @@
 //         os.Exit(1)
 //     }
 //
+// With the v2 migrations package, skip InitS3 and give the S3 reader to a
+// Migrator instead, so the rest of the process is unaffected:
+//
+//     s3r, err := remote.NewS3Reader(viper.GetString("region"))
+//     if err != nil {
+//         return err
+//     }
+//
+//     options := migrations.DefaultOptions().
+//         WithDirectory(viper.GetString("bucket")).
+//         WithReader(s3r)
+//
+//     if err := migrations.NewMigrator(options).Apply(conn); err != nil {
+//         fmt.Fprintf(os.Stderr, "Failed to migrate: %s\n", err)
+//         os.Exit(1)
+//     }
+//
 // See the remote/cmd package for examples (or feel free to use them in your
 // own spf13/cobra and spf13/viper applications).
 package remote
@@ -0,0 +1,179 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AppliedRecord describes a migration applied to the database.  Migrations applied by an earlier
+// version of the migrations package are missing some or all of the details.
+type AppliedRecord struct {
+	Migration      string        // The migration filename
+	Revision       int64         // The revision number of the migration
+	Checksum       string        // The checksum of the "up" SQL, or blank if unknown
+	AppliedAt      time.Time     // When the migration was applied, or zero if unknown
+	Duration       time.Duration // How long the migration took to run
+	AppliedBy      string        // The database user that applied the migration
+	Actor          string        // The actor configured with Options.WithActor, if any
+	AppVersion     string        // The application version configured with Options.WithAppVersion
+	LibraryVersion string        // The version of the migrations package that applied the migration
+	Dirty          bool          // A /notx migration failed partway and the database needs repair
+	Batch          int64         // The call to Apply that applied the migration, or 0 if unknown
+}
+
+// appliedColumns are added to migrations.applied tables created by earlier versions of the
+// migrations package.
+var appliedColumns = []string{
+	"checksum varchar(64)",
+	"applied_at timestamptz",
+	"duration_ms bigint",
+	"applied_by varchar(1024)",
+	"actor varchar(1024)",
+	"app_version varchar(1024)",
+	"library_version varchar(64)",
+	"dirty boolean not null default false",
+	"batch bigint",
+}
+
+// appliedSelect reads each column of migrations.applied for an AppliedRecord, with the value to
+// use if the column hasn't been added yet.
+var appliedSelect = []struct {
+	name    string
+	expr    string
+	missing string
+}{
+	{"checksum", "coalesce(checksum, '')", "''"},
+	{"applied_at", "applied_at", "null::timestamptz"},
+	{"duration_ms", "coalesce(duration_ms, 0)", "0"},
+	{"applied_by", "coalesce(applied_by, '')", "''"},
+	{"actor", "coalesce(actor, '')", "''"},
+	{"app_version", "coalesce(app_version, '')", "''"},
+	{"library_version", "coalesce(library_version, '')", "''"},
+	{"dirty", "dirty", "false"},
+	{"batch", "coalesce(batch, 0)", "0"},
+}
+
+// AppliedMigrations returns the details of the migrations applied to the database, in revision
+// order.
+func AppliedMigrations(conn Queryable) ([]AppliedRecord, error) {
+	return std().AppliedMigrations(conn)
+}
+
+// AppliedMigrationsContext returns the details of the migrations applied to the database, as with
+// AppliedMigrations.
+func AppliedMigrationsContext(ctx context.Context, conn QueryableContext) ([]AppliedRecord, error) {
+	return std().AppliedMigrationsContext(ctx, conn)
+}
+
+// AppliedMigrations returns the details of the migrations applied to the database, in revision
+// order.
+func (m *Migrator) AppliedMigrations(conn Queryable) ([]AppliedRecord, error) {
+	return m.AppliedMigrationsContext(context.Background(), withContext(conn))
+}
+
+// AppliedMigrationsContext returns the details of the migrations applied to the database, as with
+// AppliedMigrations.
+func (m *Migrator) AppliedMigrationsContext(ctx context.Context, conn QueryableContext) ([]AppliedRecord, error) {
+	return m.appliedMigrations(ctx, conn, nil)
+}
+
+// Returns the details of the applied migrations.  If present isn't nil, columns missing from it,
+// i.e. not yet added to a migrations.applied table created by an earlier release, are read as
+// blank, without altering the table.
+func (m *Migrator) appliedMigrations(ctx context.Context, conn QueryableContext, present map[string]bool) ([]AppliedRecord, error) {
+	columns := []string{"migration"}
+	for _, column := range appliedSelect {
+		if present == nil || present[column.name] {
+			columns = append(columns, column.expr)
+		} else {
+			columns = append(columns, column.missing)
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, "select "+strings.Join(columns, ", ")+" from "+m.appliedTable())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var results []AppliedRecord
+	for rows.Next() {
+		var record AppliedRecord
+		var appliedAt sql.NullTime
+		var duration int64
+
+		if err := rows.Scan(&record.Migration, &record.Checksum, &appliedAt, &duration,
+			&record.AppliedBy, &record.Actor, &record.AppVersion, &record.LibraryVersion,
+			&record.Dirty, &record.Batch); err != nil {
+			return nil, err
+		}
+
+		record.Revision, _ = Revision(record.Migration)
+		record.AppliedAt = appliedAt.Time
+		record.Duration = time.Duration(duration) * time.Millisecond
+
+		results = append(results, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Revision < results[j].Revision
+	})
+
+	return results, nil
+}
+
+// Returns the names of the columns in migrations.applied.
+func (m *Migrator) appliedColumnNames(ctx context.Context, conn QueryableContext) (map[string]bool, error) {
+	return m.columnNames(ctx, conn, m.appliedName())
+}
+
+// Returns the names of the columns in the table in the tracking schema.
+func (m *Migrator) columnNames(ctx context.Context, conn QueryableContext, table string) (map[string]bool, error) {
+	rows, err := conn.QueryContext(ctx, "select column_name from information_schema.columns "+
+		"where table_schema = $1 and table_name = $2", m.schemaName(), table)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		names[name] = true
+	}
+
+	return names, rows.Err()
+}
+
+// Returns nil for a zero batch, i.e. migrations not applied by Apply, so the column is null.
+func nullableBatch(batch int64) any {
+	if batch == 0 {
+		return nil
+	}
+
+	return batch
+}
+
+// Returns nil for a blank string, so the column is null.
+func nullable(value string) any {
+	if value == "" {
+		return nil
+	}
+
+	return value
+}
@@ -0,0 +1,507 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AsyncState is the state of an asynchronous migration, recorded in the migrations.applied_async
+// table.
+type AsyncState string
+
+const (
+	// AsyncQueued migrations are waiting for the background migrations ahead of them.
+	AsyncQueued AsyncState = "queued"
+
+	// AsyncRunning migrations are running in the background.
+	AsyncRunning AsyncState = "running"
+
+	// AsyncSucceeded migrations completed and were recorded in migrations.applied.
+	AsyncSucceeded AsyncState = "succeeded"
+
+	// AsyncFailed migrations were rolled back and aren't applied.  They're retried the next time
+	// the migrations are applied.
+	AsyncFailed AsyncState = "failed"
+)
+
+// ErrAsyncFailed returned if an asynchronous migration failed.  Use errors.As with an *AsyncError
+// to get the details.
+var ErrAsyncFailed = errors.New("asynchronous migration failed")
+
+// AsyncError describes an asynchronous migration that failed.
+type AsyncError struct {
+	Migration string // The migration filename
+	Statement SQL    // The SQL statement that failed, if known
+	Err       error  // The error returned by the database
+}
+
+// Error describes the failed migration.
+func (e *AsyncError) Error() string {
+	if e.Statement == "" {
+		return fmt.Sprintf("%s: %s: %s", ErrAsyncFailed, e.Migration, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %s failed at %q: %s", ErrAsyncFailed, e.Migration, e.Statement, e.Err)
+}
+
+// Is matches ErrAsyncFailed.
+func (e *AsyncError) Is(target error) bool {
+	return target == ErrAsyncFailed
+}
+
+// Unwrap returns the error returned by the database.
+func (e *AsyncError) Unwrap() error {
+	return e.Err
+}
+
+// AsyncStatus describes an asynchronous migration queued by ApplyAsync.
+type AsyncStatus struct {
+	Migration string     // The migration filename
+	Direction Direction  // The direction the migration is running
+	State     AsyncState // Where the migration stands
+	Statement SQL        // The statement that failed, if the migration failed
+	Error     string     // The error returned by the database, if the migration failed
+	UpdatedAt time.Time  // When the state last changed
+}
+
+// AsyncHandle tracks the asynchronous migrations running in the background after ApplyAsync
+// returns.  The migrations run in order, each in its own transaction.
+type AsyncHandle struct {
+	requests RequestChannel
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	mu      sync.Mutex
+	results []AsyncResult
+	err     error
+}
+
+// Done is closed once the background migrations have finished.
+func (h *AsyncHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait blocks until the background migrations have finished.  Returns the *AsyncError from the
+// first migration that failed, if any.  Migrations queued after a failure still run.
+func (h *AsyncHandle) Wait() error {
+	<-h.done
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.err
+}
+
+// Cancel stops the background migrations.  The migration running at the time is rolled back, and
+// it and any migrations still queued are marked failed, to be retried the next time the migrations
+// are applied.  Call Wait to wait for the background migrations to stop.
+func (h *AsyncHandle) Cancel() {
+	h.cancel()
+}
+
+// Results returns the results of the background migrations completed so far.
+func (h *AsyncHandle) Results() []AsyncResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]AsyncResult(nil), h.results...)
+}
+
+// Records the result of a background migration.
+func (h *AsyncHandle) add(result AsyncResult) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.results = append(h.results, result)
+	if h.err == nil && result.Err != nil {
+		h.err = result.Err
+	}
+}
+
+// ApplyAsync applies the migrations using the default options, running the migrations with an
+// /async modifier in the background.  See Options.ApplyAsync.
+func ApplyAsync(db *sql.DB) (*AsyncHandle, error) {
+	return DefaultOptions().ApplyAsync(db)
+}
+
+// ApplyAsync applies the migrations, as with Apply, but queues the migrations with an /async
+// modifier, such as long-running backfills, to run in the background, rather than waiting for
+// them.  The asynchronous migrations run in order, each in its own transaction, but the other
+// migrations don't wait for them.  An asynchronous migration is recorded in migrations.applied
+// only once it succeeds.
+//
+// The state of each asynchronous migration is recorded in migrations.applied_async.  Migrations
+// that failed are retried the next time the migrations are applied, and migrations still queued
+// or running in another process are skipped.  If the process crashes, its migrations are left
+// queued or running; set Options.AsyncTimeout to retry them automatically, or use ResetAsync.
+//
+// Returns a handle to wait for or cancel the background migrations.  Apply, on the other hand,
+// runs the /async migrations like any other.
+func (options Options) ApplyAsync(db *sql.DB) (*AsyncHandle, error) {
+	return options.ApplyAsyncContext(context.Background(), db)
+}
+
+// ApplyAsyncContext applies the migrations, running the /async migrations in the background, as
+// with ApplyAsync.  Cancelling the context also cancels the background migrations.
+func (options Options) ApplyAsyncContext(ctx context.Context, db *sql.DB) (*AsyncHandle, error) {
+	return options.migrator().ApplyAsyncContext(ctx, db)
+}
+
+// ApplyAsync applies the migrations, running the /async migrations in the background.  See
+// Options.ApplyAsync.
+func (m *Migrator) ApplyAsync(db *sql.DB) (*AsyncHandle, error) {
+	return m.ApplyAsyncContext(context.Background(), db)
+}
+
+// ApplyAsyncContext applies the migrations, running the /async migrations in the background, as
+// with ApplyAsync.
+func (m *Migrator) ApplyAsyncContext(ctx context.Context, db *sql.DB) (*AsyncHandle, error) {
+	migrations, err := m.Available(Up)
+	if err != nil {
+		return nil, err
+	}
+
+	background, cancel := context.WithCancel(ctx)
+
+	// Worst case scenario: everything is asynchronous!
+	handle := &AsyncHandle{
+		requests: make(RequestChannel, len(migrations)),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	go m.handleAsync(background, db, handle)
+
+	async := m.with(m.options)
+	async.async = handle
+
+	err = async.ApplyContext(ctx, db)
+	close(handle.requests)
+
+	if err != nil {
+		cancel()
+		<-handle.done
+		return nil, err
+	}
+
+	return handle, nil
+}
+
+// Runs the queued asynchronous migrations in order until the requests are closed.
+func (m *Migrator) handleAsync(ctx context.Context, db *sql.DB, handle *AsyncHandle) {
+	defer close(handle.done)
+	defer handle.cancel()
+
+	for req := range handle.requests {
+		filename := Filename(req.Migration)
+
+		cmd, err := m.runAsync(ctx, db, req)
+		if err == nil {
+			handle.add(AsyncResult{Migration: filename})
+			continue
+		}
+
+		// The context may be cancelled, but the failure must still be recorded
+		if err := m.setAsyncState(context.Background(), db, filename, req.Direction, AsyncFailed, cmd, err); err != nil {
+			m.log.Infof("Unable to record the failure of %s: %s", filename, err)
+		}
+
+		err = m.failed(db, req.Migration, req.Direction, &AsyncError{Migration: filename, Statement: cmd, Err: err})
+		m.log.Infof("Migration %s %s failed: %s", req.Migration, req.Direction, err)
+
+		handle.add(AsyncResult{Migration: filename, Err: err, Command: cmd})
+	}
+}
+
+// Runs the asynchronous migration in a transaction, recording it in migrations.applied if it
+// succeeds.  Returns the statement that failed, if any.
+func (m *Migrator) runAsync(ctx context.Context, db *sql.DB, req AsyncRequest) (SQL, error) {
+	filename := Filename(req.Migration)
+
+	if err := m.setAsyncState(ctx, db, filename, req.Direction, AsyncRunning, "", nil); err != nil {
+		return "", err
+	}
+
+	m.log.Infof("Running migration %s %s asynchronously", req.Migration, req.Direction)
+
+	start := time.Now()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if cmd, err := runIsolated(ctx, tx, req.SQL); err != nil {
+		_ = tx.Rollback()
+		return cmd, err
+	}
+
+	if err := m.inBatch(req.Batch).migrated(ctx, tx, req.Migration, req.Direction, time.Since(start)); err != nil {
+		_ = tx.Rollback()
+		return "", err
+	}
+
+	if err := m.setAsyncState(ctx, tx, filename, req.Direction, AsyncSucceeded, "", nil); err != nil {
+		_ = tx.Rollback()
+		return "", err
+	}
+
+	return "", tx.Commit()
+}
+
+// Prepares a migration with an /async modifier, in the transaction checking whether it should
+// run.  Skip is true if the migration shouldn't run now:  either it's queued or running in
+// another process, or the request returned should be sent to the background once the transaction
+// is committed.  Otherwise, the migration runs synchronously, as with Apply.  Migrations that
+// failed earlier, or that have been queued or running for longer than Options.AsyncTimeout, are
+// logged and retried.
+func (m *Migrator) prepareAsync(ctx context.Context, tx *sql.Tx, path string, direction Direction, doc SQL) (req *AsyncRequest, skip bool, err error) {
+	filename := Filename(path)
+
+	record, err := m.asyncRecord(ctx, tx, filename, true)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch record.state {
+	case AsyncQueued, AsyncRunning:
+		if m.asyncBusy(record) {
+			m.log.Infof("Skipping migration %s %s; it's %s asynchronously", path, direction, record.state)
+			return nil, true, nil
+		}
+
+		// The process running the migration likely crashed
+		m.log.Infof("Retrying migration %s %s, which has been %s asynchronously for %s", path, direction,
+			record.state, record.idle.Round(time.Second))
+	case AsyncFailed:
+		m.log.Infof("Retrying migration %s %s, which failed asynchronously at %q: %s", path, direction,
+			record.statement, record.message)
+	}
+
+	if m.async == nil {
+		// Running synchronously, so forget any earlier failure
+		if _, err := tx.ExecContext(ctx, "delete from "+m.asyncTable()+" where migration = $1", filename); err != nil {
+			return nil, false, err
+		}
+
+		return nil, false, nil
+	}
+
+	if err := m.setAsyncState(ctx, tx, filename, direction, AsyncQueued, "", nil); err != nil {
+		return nil, false, err
+	}
+
+	m.log.Infof("Queueing migration %s %s to run asynchronously", path, direction)
+	return &AsyncRequest{Migration: path, Direction: direction, SQL: doc, Target: m.options.Revision,
+		Batch: m.batch}, true, nil
+}
+
+// The state of an asynchronous migration recorded in migrations.applied_async.
+type asyncRecord struct {
+	state     AsyncState
+	statement string
+	message   string
+	idle      time.Duration // How long since the state changed
+}
+
+// Returns the recorded state of the asynchronous migration, or a blank state if it hasn't been
+// run asynchronously.  Locks the record until the end of the transaction if forUpdate is true.
+func (m *Migrator) asyncRecord(ctx context.Context, conn QueryableContext, migration string, forUpdate bool) (asyncRecord, error) {
+	query := "select state, coalesce(statement, ''), coalesce(error, ''), " +
+		"extract(epoch from now() - updated_at) * 1000 from " + m.asyncTable() + " where migration = $1"
+	if forUpdate {
+		query += " for update"
+	}
+
+	var record asyncRecord
+	var idleMS float64
+
+	// The idle time is measured by the database clock
+	row := conn.QueryRowContext(ctx, query, migration)
+	if err := row.Scan(&record.state, &record.statement, &record.message, &idleMS); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return record, err
+	}
+
+	record.idle = time.Duration(idleMS) * time.Millisecond
+	return record, nil
+}
+
+// Returns true if the asynchronous migration is queued or running in another process, and hasn't
+// exceeded Options.AsyncTimeout, so Apply skips it.
+func (m *Migrator) asyncBusy(record asyncRecord) bool {
+	if record.state != AsyncQueued && record.state != AsyncRunning {
+		return false
+	}
+
+	return m.options.AsyncTimeout <= 0 || record.idle < m.options.AsyncTimeout
+}
+
+// Records the state of the asynchronous migration.
+func (m *Migrator) setAsyncState(ctx context.Context, conn execer, migration string, direction Direction, state AsyncState, statement SQL, failure error) error {
+	var message string
+	if failure != nil {
+		message = failure.Error()
+	}
+
+	_, err := conn.ExecContext(ctx, "insert into "+m.asyncTable()+" "+
+		"(migration, direction, state, statement, error, updated_at) values ($1, $2, $3, $4, $5, now()) "+
+		"on conflict (migration) do update set direction = excluded.direction, state = excluded.state, "+
+		"statement = excluded.statement, error = excluded.error, updated_at = excluded.updated_at",
+		migration, string(direction), string(state), nullable(string(statement)), nullable(message))
+	return err
+}
+
+// Returns the asynchronous migrations that are queued, running, or failed, mapped by filename.
+func (m *Migrator) pendingAsync(ctx context.Context, conn QueryableContext) (map[string]bool, error) {
+	rows, err := conn.QueryContext(ctx, "select migration from "+m.asyncTable()+" where state <> $1",
+		string(AsyncSucceeded))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	pending := make(map[string]bool)
+	for rows.Next() {
+		var migration string
+		if err := rows.Scan(&migration); err != nil {
+			return nil, err
+		}
+
+		pending[migration] = true
+	}
+
+	return pending, rows.Err()
+}
+
+// AsyncMigrations returns the state of the asynchronous migrations queued by ApplyAsync, in
+// revision order.
+func AsyncMigrations(conn Queryable) ([]AsyncStatus, error) {
+	return std().AsyncMigrations(conn)
+}
+
+// AsyncMigrationsContext returns the state of the asynchronous migrations, as with
+// AsyncMigrations.
+func AsyncMigrationsContext(ctx context.Context, conn QueryableContext) ([]AsyncStatus, error) {
+	return std().AsyncMigrationsContext(ctx, conn)
+}
+
+// AsyncMigrations returns the state of the asynchronous migrations queued by ApplyAsync, in
+// revision order.
+func (m *Migrator) AsyncMigrations(conn Queryable) ([]AsyncStatus, error) {
+	return m.AsyncMigrationsContext(context.Background(), withContext(conn))
+}
+
+// AsyncMigrationsContext returns the state of the asynchronous migrations, as with
+// AsyncMigrations.
+func (m *Migrator) AsyncMigrationsContext(ctx context.Context, conn QueryableContext) ([]AsyncStatus, error) {
+	rows, err := conn.QueryContext(ctx, "select migration, direction, state, coalesce(statement, ''), "+
+		"coalesce(error, ''), updated_at from "+m.asyncTable())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var results []AsyncStatus
+	for rows.Next() {
+		var status AsyncStatus
+		if err := rows.Scan(&status.Migration, &status.Direction, &status.State, &status.Statement,
+			&status.Error, &status.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		results = append(results, status)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		iRev, _ := Revision(results[i].Migration)
+		jRev, _ := Revision(results[j].Migration)
+		return iRev < jRev
+	})
+
+	return results, nil
+}
+
+// ResetAsync clears the state of the asynchronous migration with the revision from
+// migrations.applied_async, so it runs again the next time the migrations are applied.  Use it
+// when a migration is left queued or running because the process running it crashed.  Check the
+// migration isn't still running in another process first.
+func ResetAsync(db *sql.DB, options Options, revision int64) error {
+	return ResetAsyncContext(context.Background(), db, options, revision)
+}
+
+// ResetAsyncContext clears the state of the asynchronous migration, as with ResetAsync.
+func ResetAsyncContext(ctx context.Context, db *sql.DB, options Options, revision int64) error {
+	return options.migrator().ResetAsyncContext(ctx, db, revision)
+}
+
+// ResetAsync clears the state of the asynchronous migration with the revision.  See the
+// package-level ResetAsync.
+func (m *Migrator) ResetAsync(db *sql.DB, revision int64) error {
+	return m.ResetAsyncContext(context.Background(), db, revision)
+}
+
+// ResetAsyncContext clears the state of the asynchronous migration, as with ResetAsync.
+func (m *Migrator) ResetAsyncContext(ctx context.Context, db *sql.DB, revision int64) error {
+	return m.repairTx(ctx, db, func(tx *sql.Tx) error {
+		statuses, err := m.AsyncMigrationsContext(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			if rev, err := Revision(status.Migration); err != nil || rev != revision || status.State == AsyncSucceeded {
+				continue
+			}
+
+			if _, err := tx.ExecContext(ctx, "delete from "+m.asyncTable()+" where migration = $1",
+				status.Migration); err != nil {
+				return fmt.Errorf("unable to reset %s: %w", status.Migration, err)
+			}
+
+			if err := m.recordHistory(ctx, tx, HistoryRecord{
+				Migration: status.Migration,
+				Event:     HistoryRepair,
+				Direction: status.Direction,
+			}); err != nil {
+				return err
+			}
+
+			m.log.Infof("Reset asynchronous migration %s, which was %s; it runs again the next time the "+
+				"migrations are applied", status.Migration, status.State)
+			return nil
+		}
+
+		return fmt.Errorf("%w %d queued, running, or failed asynchronously", ErrUnknownRevision, revision)
+	})
+}
+
+func (m *Migrator) createMigrationsAsync(ctx context.Context, tx *sql.Tx) error {
+	if m.missingTable(ctx, tx, m.asyncName()) {
+		m.log.Infof("Creating %s.%s table in the database", m.schemaName(), m.asyncName())
+		if _, err := tx.ExecContext(ctx, "create table "+m.asyncTable()+"(migration varchar(1024) not null primary key, "+
+			"direction varchar(8) not null, state varchar(16) not null, statement text, error text, "+
+			"updated_at timestamptz not null default now())"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// execer runs SQL statements, such as a *sql.DB or *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
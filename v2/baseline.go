@@ -0,0 +1,124 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrNotEmpty returned by Baseline if migrations have already been recorded in the tracking
+// tables.
+var ErrNotEmpty = errors.New("migrations already recorded")
+
+// Baseline adopts an existing database, built by hand or with another tool, at the revision.
+// Every migration up to and including the revision is marked as applied without running it, and
+// its "down" SQL is stored in migrations.rollbacks, so it may be rolled back later.  If revision
+// is Latest, every available migration is marked as applied.
+//
+// Returns an error matching ErrNotEmpty if migrations.applied or migrations.rollbacks already
+// contain rows.  Use ForceBaseline to baseline anyway.
+func Baseline(db *sql.DB, options Options, revision int64) error {
+	return BaselineContext(context.Background(), db, options, revision)
+}
+
+// BaselineContext adopts an existing database at the revision, as with Baseline.
+func BaselineContext(ctx context.Context, db *sql.DB, options Options, revision int64) error {
+	return options.migrator().BaselineContext(ctx, db, revision)
+}
+
+// ForceBaseline adopts an existing database at the revision, as with Baseline, even if migrations
+// have already been recorded.  Migrations already recorded as applied are left as is.
+func ForceBaseline(db *sql.DB, options Options, revision int64) error {
+	return ForceBaselineContext(context.Background(), db, options, revision)
+}
+
+// ForceBaselineContext adopts an existing database at the revision, as with ForceBaseline.
+func ForceBaselineContext(ctx context.Context, db *sql.DB, options Options, revision int64) error {
+	return options.migrator().ForceBaselineContext(ctx, db, revision)
+}
+
+// Baseline adopts an existing database at the revision.  See the package-level Baseline.
+func (m *Migrator) Baseline(db *sql.DB, revision int64) error {
+	return m.BaselineContext(context.Background(), db, revision)
+}
+
+// BaselineContext adopts an existing database at the revision, as with Baseline.
+func (m *Migrator) BaselineContext(ctx context.Context, db *sql.DB, revision int64) error {
+	return m.locked(ctx, db, func(conn Executor) error {
+		return m.baseline(ctx, conn, revision, false)
+	})
+}
+
+// ForceBaseline adopts an existing database at the revision, even if migrations have already
+// been recorded.  See the package-level ForceBaseline.
+func (m *Migrator) ForceBaseline(db *sql.DB, revision int64) error {
+	return m.ForceBaselineContext(context.Background(), db, revision)
+}
+
+// ForceBaselineContext adopts an existing database at the revision, as with ForceBaseline.
+func (m *Migrator) ForceBaselineContext(ctx context.Context, db *sql.DB, revision int64) error {
+	return m.locked(ctx, db, func(conn Executor) error {
+		return m.baseline(ctx, conn, revision, true)
+	})
+}
+
+// Records the migrations up to the revision as applied without locking.
+func (m *Migrator) baseline(ctx context.Context, db Executor, revision int64, force bool) error {
+	if err := m.checkDuplicates(); err != nil {
+		return err
+	}
+
+	if err := m.InitializeDBContext(ctx, db); err != nil {
+		return err
+	}
+
+	migrations, err := m.Available(Up)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		var recorded bool
+		row := tx.QueryRowContext(ctx, "select exists(select 1 from "+m.appliedTable()+") or "+
+			"exists(select 1 from "+m.rollbacksTable()+")")
+		if err := row.Scan(&recorded); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if recorded {
+			_ = tx.Rollback()
+			return fmt.Errorf("%w in %s; use ForceBaseline to baseline anyway", ErrNotEmpty, m.schemaName())
+		}
+	}
+
+	var count int
+	for _, migration := range migrations {
+		path := m.path(migration)
+
+		if !m.shouldRun(ctx, tx, path, Up, revision) {
+			continue
+		}
+
+		if err := m.track(ctx, tx, HistoryBaseline, path, Up, 0); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("unable to baseline %s: %w", migration, err)
+		}
+
+		m.log.Infof("Baselined migration %s", migration)
+		count++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	m.log.Infof("Baselined %d migration(s)", count)
+	return nil
+}
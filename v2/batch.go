@@ -0,0 +1,150 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+)
+
+// Returns the batch ID for the migrations applied by the next call to Apply, one more than the
+// highest batch in migrations.applied.
+func (m *Migrator) nextBatch(ctx context.Context, db QueryableContext) (int64, error) {
+	var batch int64
+
+	row := db.QueryRowContext(ctx, "select coalesce(max(batch), 0) + 1 from "+m.appliedTable())
+	if err := row.Scan(&batch); err != nil {
+		return 0, err
+	}
+
+	return batch, nil
+}
+
+// Returns a copy of the migrator that stamps the migrations it applies with the batch ID.
+func (m *Migrator) inBatch(batch int64) *Migrator {
+	b := m.with(m.options)
+	b.async = m.async
+	b.batch = batch
+
+	return b
+}
+
+// RollbackBatch rolls back the migrations applied by the last n calls to Apply, newest first,
+// using the "down" migrations stored in the database.  If n is less than 2, rolls back the last
+// batch.
+//
+// Migrations applied by earlier versions of the migrations package don't belong to a batch, and
+// aren't rolled back.  May return ErrStopped if a rollback has a /stop modifier, or ErrNoRollback
+// if there's no "down" migration stored for a migration in a batch.
+func RollbackBatch(db *sql.DB, n int) error {
+	return RollbackBatchContext(context.Background(), db, n)
+}
+
+// RollbackBatchContext rolls back the migrations applied by the last n calls to Apply, as with
+// RollbackBatch, but stops if the context is cancelled.
+func RollbackBatchContext(ctx context.Context, db *sql.DB, n int) error {
+	return std().RollbackBatchContext(ctx, db, n)
+}
+
+// RollbackBatch rolls back the migrations applied by the last n calls to Apply.  See the
+// package-level RollbackBatch.
+func (options Options) RollbackBatch(db *sql.DB, n int) error {
+	return options.RollbackBatchContext(context.Background(), db, n)
+}
+
+// RollbackBatchContext rolls back the migrations applied by the last n calls to Apply, as with
+// RollbackBatch, but stops if the context is cancelled.
+func (options Options) RollbackBatchContext(ctx context.Context, db *sql.DB, n int) error {
+	return options.migrator().RollbackBatchContext(ctx, db, n)
+}
+
+// RollbackBatch rolls back the migrations applied by the last n calls to Apply.  See the
+// package-level RollbackBatch.
+func (m *Migrator) RollbackBatch(db *sql.DB, n int) error {
+	return m.RollbackBatchContext(context.Background(), db, n)
+}
+
+// RollbackBatchContext rolls back the migrations applied by the last n calls to Apply, as with
+// RollbackBatch, but stops if the context is cancelled.  Holds the migrations lock, unless
+// disabled, while rolling back.
+func (m *Migrator) RollbackBatchContext(ctx context.Context, db *sql.DB, n int) error {
+	return m.locked(ctx, db, func(conn Executor) error {
+		return m.rollbackBatch(ctx, conn, n)
+	})
+}
+
+// Rolls back the last n batches without locking.
+func (m *Migrator) rollbackBatch(ctx context.Context, db Executor, n int) error {
+	if n < 2 {
+		n = 1
+	}
+
+	if err := m.InitializeDBContext(ctx, db); err != nil {
+		return err
+	}
+
+	if err := m.checkDirty(ctx, db); err != nil {
+		return err
+	}
+
+	migrations, err := m.batchMigrations(ctx, db, n)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		if err := m.rollbackStored(ctx, db, migration); err != nil {
+			return m.failed(db, m.path(migration), Down, err)
+		}
+	}
+
+	return nil
+}
+
+// Returns the migrations in the last n batches, in the order they should be rolled back:  the
+// latest batch first, and within a batch, the highest revision first.
+func (m *Migrator) batchMigrations(ctx context.Context, db QueryableContext, n int) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "select migration, batch from "+m.appliedTable()+" "+
+		"where batch in (select distinct batch from "+m.appliedTable()+" where batch is not null "+
+		"order by batch desc limit $1)", n)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	type batched struct {
+		migration string
+		batch     int64
+	}
+
+	var found []batched
+	for rows.Next() {
+		var b batched
+		if err := rows.Scan(&b.migration, &b.batch); err != nil {
+			return nil, err
+		}
+
+		found = append(found, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Revisions are parsed from the filenames, so sort here rather than in the query
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].batch != found[j].batch {
+			return found[i].batch > found[j].batch
+		}
+
+		return SortDown{found[i].migration, found[j].migration}.Less(0, 1)
+	})
+
+	var migrations []string
+	for _, b := range found {
+		migrations = append(migrations, b.migration)
+	}
+
+	return migrations, nil
+}
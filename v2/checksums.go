@@ -0,0 +1,290 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrChecksumMismatch returned if a migration file was modified after it was applied to the
+// database.  Use errors.As with a *ChecksumError to get the details.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ChecksumError describes a migration file whose "up" SQL no longer matches the checksum recorded
+// when the migration was applied.
+type ChecksumError struct {
+	Migration string // The migration filename
+	Expected  string // The checksum recorded in migrations.applied
+	Actual    string // The checksum of the migration file
+}
+
+// Error describes the mismatch.
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%s: %s was modified after it was applied", ErrChecksumMismatch, e.Migration)
+}
+
+// Is matches ErrChecksumMismatch.
+func (e *ChecksumError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+// Checksum returns the SHA-256 hash of the "up" SQL in the migration.  Whitespace surrounding the
+// SQL is ignored, and templates aren't rendered, so the checksum doesn't vary with the template
+// variables.
+func Checksum(path string) (string, error) {
+	return std().Checksum(path)
+}
+
+// Checksum returns the SHA-256 hash of the "up" SQL in the migration, read using the migrator's
+// Reader.  Go migrations have no checksum, so return a blank string.
+func (m *Migrator) Checksum(path string) (string, error) {
+	if _, ok := m.funcs.lookup(path); ok {
+		return "", nil
+	}
+
+	SQL, _, err := m.readSQL(path, Up)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(strings.TrimSpace(string(SQL))))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify compares the checksums recorded in migrations.applied against the migration files in the
+// directory, and returns the details of every file modified since it was applied.  Migrations
+// without a file in the directory, or without a recorded checksum, are skipped.
+func Verify(db *sql.DB, directory string) ([]*ChecksumError, error) {
+	return VerifyContext(context.Background(), db, directory)
+}
+
+// VerifyContext compares the recorded checksums against the migration files, as with Verify.
+func VerifyContext(ctx context.Context, db *sql.DB, directory string) ([]*ChecksumError, error) {
+	return WithDirectory(directory).migrator().VerifyContext(ctx, db)
+}
+
+// Verify compares the recorded checksums against the migration files in the migrations directory.
+// See the package Verify function.
+func (m *Migrator) Verify(db Executor) ([]*ChecksumError, error) {
+	return m.VerifyContext(context.Background(), db)
+}
+
+// VerifyContext compares the recorded checksums against the migration files, as with Verify.
+func (m *Migrator) VerifyContext(ctx context.Context, db Executor) ([]*ChecksumError, error) {
+	return m.verify(ctx, db)
+}
+
+// Compares the recorded checksums against the migration files.
+func (m *Migrator) verify(ctx context.Context, conn QueryableContext) ([]*ChecksumError, error) {
+	recorded, err := m.checksums(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := m.Available(Up)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []*ChecksumError
+	for _, migration := range migrations {
+		expected := recorded[migration]
+		if expected == "" {
+			continue
+		}
+
+		actual, err := m.Checksum(m.path(migration))
+		if err != nil {
+			return nil, err
+		}
+
+		if actual != expected {
+			mismatches = append(mismatches, &ChecksumError{
+				Migration: migration,
+				Expected:  expected,
+				Actual:    actual,
+			})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// RepairChecksums records the current checksum of every applied migration file in the directory.
+// Use this once changes to the migration files have been reviewed.  Returns the number of
+// checksums that were updated.
+func RepairChecksums(db *sql.DB, directory string) (int, error) {
+	return RepairChecksumsContext(context.Background(), db, directory)
+}
+
+// RepairChecksumsContext records the current checksum of every applied migration file, as with
+// RepairChecksums.
+func RepairChecksumsContext(ctx context.Context, db *sql.DB, directory string) (int, error) {
+	return WithDirectory(directory).migrator().RepairChecksumsContext(ctx, db)
+}
+
+// RepairChecksums records the current checksum of every applied migration file in the migrations
+// directory.  See the package RepairChecksums function.
+func (m *Migrator) RepairChecksums(db Executor) (int, error) {
+	return m.RepairChecksumsContext(context.Background(), db)
+}
+
+// RepairChecksumsContext records the current checksum of every applied migration file, as with
+// RepairChecksums.
+func (m *Migrator) RepairChecksumsContext(ctx context.Context, db Executor) (int, error) {
+	recorded, err := m.checksums(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	migrations, err := m.Available(Up)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var updated int
+	for _, migration := range migrations {
+		expected, ok := recorded[migration]
+		if !ok {
+			continue
+		}
+
+		actual, err := m.Checksum(m.path(migration))
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+
+		if actual == expected {
+			continue
+		}
+
+		m.log.Infof("Updating the checksum for %s", migration)
+		if _, err := tx.ExecContext(ctx, "update "+m.appliedTable()+" set checksum = $1 where migration = $2", actual, migration); err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+
+		if err := m.recordHistory(ctx, tx, HistoryRecord{
+			Migration: migration,
+			Event:     HistoryRepair,
+			Direction: Up,
+			Checksum:  actual,
+			Previous:  expected,
+		}); err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+
+		updated++
+	}
+
+	return updated, tx.Commit()
+}
+
+// CheckChecksums verifies the applied migration files haven't been modified, according to the
+// policy.  Migrations applied before checksums were recorded have their checksums stamped.
+func CheckChecksums(db *sql.DB, directory string, policy Policy) error {
+	return WithDirectory(directory).migrator().checkChecksums(context.Background(), db, policy)
+}
+
+func (m *Migrator) checkChecksums(ctx context.Context, db Executor, policy Policy) error {
+	if policy == Allow {
+		return nil
+	}
+
+	if err := m.verifyChecksums(ctx, db, policy); err != nil {
+		return err
+	}
+
+	return m.stampChecksums(ctx, db)
+}
+
+// Returns a *ChecksumError for the first modified migration file if the policy is Error, or logs
+// the modified files if it's Warn.  Doesn't change the database.
+func (m *Migrator) verifyChecksums(ctx context.Context, conn QueryableContext, policy Policy) error {
+	if policy == Allow {
+		return nil
+	}
+
+	mismatches, err := m.verify(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, mismatch := range mismatches {
+		if policy == Error {
+			return mismatch
+		}
+
+		m.log.Infof("Warning: %s", mismatch)
+	}
+
+	return nil
+}
+
+// stampChecksums records the checksum for any applied migrations missing one, i.e. those applied
+// before checksums were introduced.
+func (m *Migrator) stampChecksums(ctx context.Context, db Executor) error {
+	recorded, err := m.checksums(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	migrations, err := m.Available(Up)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		if sum, ok := recorded[migration]; !ok || sum != "" {
+			continue
+		}
+
+		actual, err := m.Checksum(m.path(migration))
+		if err != nil {
+			return err
+		} else if actual == "" {
+			continue
+		}
+
+		if _, err := db.ExecContext(ctx, "update "+m.appliedTable()+" set checksum = $1 where migration = $2 and checksum is null", actual, migration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns the recorded checksums for all the applied migrations, mapped by filename.  Migrations
+// applied before checksums were introduced map to a blank string.
+func (m *Migrator) checksums(ctx context.Context, conn QueryableContext) (map[string]string, error) {
+	rows, err := conn.QueryContext(ctx, "select migration, coalesce(checksum, '') from "+m.appliedTable())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	results := make(map[string]string)
+	for rows.Next() {
+		var migration, checksum string
+		if err := rows.Scan(&migration, &checksum); err != nil {
+			return nil, err
+		}
+
+		results[migration] = checksum
+	}
+
+	return results, rows.Err()
+}
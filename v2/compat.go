@@ -14,5 +14,5 @@ import "database/sql"
 // Indicate the version to roll towards, either forwards or backwards (rollback).  By default, we
 // roll forwards to the current time, i.e. run all the migrations.
 func Migrate(db *sql.DB, directory string, version int) error {
-	return WithDirectory(directory).WithRevision(version).Apply(db)
+	return WithDirectory(directory).WithRevision(int64(version)).Apply(db)
 }
@@ -0,0 +1,136 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotRegistered returned if a Go migration applied to the database must be rolled back, but
+// the application no longer registers it, so there's no down function to run.
+var ErrNotRegistered = errors.New("go migration not registered")
+
+// Stored in migrations.rollbacks in place of the down SQL for Go migrations, which run the down
+// function registered with the application instead.
+const funcRollback = "/func"
+
+// MigrationFunc migrates the database in Go, for migrations that can't be written in SQL, such as
+// re-encrypting columns.  The function runs in the migration's transaction, which is committed
+// along with the record in migrations.applied.
+type MigrationFunc func(ctx context.Context, tx *sql.Tx) error
+
+// Registry holds the Go migrations registered with an application.  A Registry is safe for
+// concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	funcs map[string]funcMigration
+}
+
+// A Go migration's up and down functions.
+type funcMigration struct {
+	up   MigrationFunc
+	down MigrationFunc
+}
+
+// Funcs is the registry of Go migrations used by the package-level functions and the default
+// options.  See Register.
+var Funcs = NewRegistry()
+
+// NewRegistry creates an empty registry of Go migrations, to supply to WithRegistry.
+func NewRegistry() *Registry {
+	return &Registry{funcs: make(map[string]funcMigration)}
+}
+
+// Register adds a Go migration to the Funcs registry, typically from an init function.  See
+// Registry.Register.
+func Register(revision int64, name string, up, down MigrationFunc) {
+	Funcs.Register(revision, name, up, down)
+}
+
+// Register adds a Go migration to the registry.  The migration is merged into the ordering of the
+// SQL migration files by its revision, and is tracked in migrations.applied as
+// "<revision>-<name>.go", e.g. "12-reencrypt-ssn.go".  The down function may be nil if the
+// migration can't be rolled back, in which case rolling back does nothing but remove the
+// migration from migrations.applied.
+//
+// Register panics if the name is blank, the revision isn't positive, up is nil, or a migration
+// with the same revision and name was already registered.
+func (r *Registry) Register(revision int64, name string, up, down MigrationFunc) {
+	name = strings.TrimSpace(name)
+	if name == "" || revision < 1 {
+		panic(fmt.Sprintf("migrations: invalid Go migration %d-%s", revision, name))
+	}
+
+	if up == nil {
+		panic(fmt.Sprintf("migrations: Go migration %d-%s has no up function", revision, name))
+	}
+
+	filename := fmt.Sprintf("%d-%s.go", revision, name)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.funcs[filename]; ok {
+		panic(fmt.Sprintf("migrations: Go migration %s registered twice", filename))
+	}
+
+	r.funcs[filename] = funcMigration{up: up, down: down}
+}
+
+// Migrations returns the filenames of the registered Go migrations, in revision order.
+func (r *Registry) Migrations() []string {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var filenames []string
+	for filename := range r.funcs {
+		filenames = append(filenames, filename)
+	}
+
+	sort.Sort(SortUp(filenames))
+	return filenames
+}
+
+// Returns the registered Go migration for the migration filename or path.
+func (r *Registry) lookup(migration string) (funcMigration, bool) {
+	if r == nil {
+		return funcMigration{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fn, ok := r.funcs[Filename(migration)]
+	return fn, ok
+}
+
+// Runs the up or down function in the transaction.
+func (fn funcMigration) run(ctx context.Context, tx *sql.Tx, direction Direction) error {
+	if direction == Down {
+		if fn.down == nil {
+			return nil
+		}
+
+		return fn.down(ctx, tx)
+	}
+
+	return fn.up(ctx, tx)
+}
+
+// Runs the migration's SQL, or its function if it's a registered Go migration.
+func (m *Migrator) exec(ctx context.Context, tx *sql.Tx, migration string, direction Direction, SQL SQL) error {
+	if fn, ok := m.funcs.lookup(migration); ok {
+		return fn.run(ctx, tx, direction)
+	}
+
+	_, err := tx.ExecContext(ctx, string(SQL))
+	return err
+}
@@ -0,0 +1,204 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HistoryEvent is the kind of change recorded in the migrations history.
+type HistoryEvent string
+
+const (
+	// HistoryApply records a migration applied to the database.
+	HistoryApply HistoryEvent = "apply"
+
+	// HistoryRollback records a migration rolled back.
+	HistoryRollback HistoryEvent = "rollback"
+
+	// HistoryFailure records a migration, rollback, or hook that failed.
+	HistoryFailure HistoryEvent = "failure"
+
+	// HistoryBaseline records a migration marked as applied by Baseline.
+	HistoryBaseline HistoryEvent = "baseline"
+
+	// HistoryRepair records a change to the tracking tables by MarkApplied, Unmark,
+	// ForceRevision, ResetAsync, or RepairChecksums.
+	HistoryRepair HistoryEvent = "repair"
+)
+
+// HistoryRecord is an event in the migrations history.
+type HistoryRecord struct {
+	ID         int64         // Orders the events
+	Migration  string        // The migration filename, or blank if the failure wasn't in a migration
+	Revision   int64         // The revision number of the migration
+	Event      HistoryEvent  // What happened
+	Direction  Direction     // The direction the migration ran
+	RecordedAt time.Time     // When the event happened
+	Duration   time.Duration // How long the migration took to run
+	Checksum   string        // The checksum of the migration file, or blank if unknown
+	Previous   string        // The checksum replaced by RepairChecksums, for repairs
+	DBUser     string        // The database user that made the change
+	Actor      string        // The actor configured with Options.WithActor, if any
+	AppVersion string        // The application version configured with Options.WithAppVersion
+	Error      string        // The error, for failures
+}
+
+// HistoryFilter limits the events returned by History.  The zero value returns every event.
+type HistoryFilter struct {
+	Migration string         // Only the events for this migration filename
+	Events    []HistoryEvent // Only these kinds of event
+	Since     time.Time      // Only the events at or after this time
+	Until     time.Time      // Only the events before this time
+	Limit     int            // Only the most recent events, if positive
+}
+
+// History returns the events in the migrations history matching the filter, oldest first.  Unlike
+// migrations.applied, the history is append-only, so it keeps a record of migrations that were
+// rolled back, failed, or repaired.
+func History(conn Queryable, filter HistoryFilter) ([]HistoryRecord, error) {
+	return std().History(conn, filter)
+}
+
+// HistoryContext returns the events in the migrations history, as with History.
+func HistoryContext(ctx context.Context, conn QueryableContext, filter HistoryFilter) ([]HistoryRecord, error) {
+	return std().HistoryContext(ctx, conn, filter)
+}
+
+// History returns the events in the migrations history matching the filter, oldest first.
+func (m *Migrator) History(conn Queryable, filter HistoryFilter) ([]HistoryRecord, error) {
+	return m.HistoryContext(context.Background(), withContext(conn), filter)
+}
+
+// HistoryContext returns the events in the migrations history, as with History.
+func (m *Migrator) HistoryContext(ctx context.Context, conn QueryableContext, filter HistoryFilter) ([]HistoryRecord, error) {
+	var where []string
+	var args []any
+
+	if filter.Migration != "" {
+		args = append(args, filter.Migration)
+		where = append(where, fmt.Sprintf("migration = $%d", len(args)))
+	}
+
+	if len(filter.Events) > 0 {
+		var events []string
+		for _, event := range filter.Events {
+			args = append(args, string(event))
+			events = append(events, fmt.Sprintf("$%d", len(args)))
+		}
+
+		where = append(where, "event in ("+strings.Join(events, ", ")+")")
+	}
+
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		where = append(where, fmt.Sprintf("recorded_at >= $%d", len(args)))
+	}
+
+	if !filter.Until.IsZero() {
+		args = append(args, filter.Until)
+		where = append(where, fmt.Sprintf("recorded_at < $%d", len(args)))
+	}
+
+	query := "select id, coalesce(migration, ''), event, coalesce(direction, ''), recorded_at, " +
+		"coalesce(duration_ms, 0), coalesce(checksum, ''), coalesce(previous_checksum, ''), " +
+		"coalesce(db_user, ''), coalesce(actor, ''), coalesce(app_version, ''), coalesce(error, '') from " +
+		m.historyTable()
+
+	if len(where) > 0 {
+		query += " where " + strings.Join(where, " and ")
+	}
+
+	// Take the most recent events, but return them oldest first
+	query += " order by id desc"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" limit $%d", len(args))
+	}
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var results []HistoryRecord
+	for rows.Next() {
+		var record HistoryRecord
+		var event, direction string
+		var duration int64
+
+		if err := rows.Scan(&record.ID, &record.Migration, &event, &direction, &record.RecordedAt,
+			&duration, &record.Checksum, &record.Previous, &record.DBUser, &record.Actor, &record.AppVersion,
+			&record.Error); err != nil {
+			return nil, err
+		}
+
+		record.Revision, _ = Revision(record.Migration)
+		record.Event = HistoryEvent(event)
+		record.Direction = Direction(direction)
+		record.Duration = time.Duration(duration) * time.Millisecond
+
+		results = append(results, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+		results[i], results[j] = results[j], results[i]
+	}
+
+	return results, nil
+}
+
+func (m *Migrator) createMigrationsHistory(ctx context.Context, tx *sql.Tx) error {
+	if m.missingTable(ctx, tx, m.historyName()) {
+		m.log.Infof("Creating %s.%s table in the database", m.schemaName(), m.historyName())
+		if _, err := tx.ExecContext(ctx, "create table "+m.historyTable()+"(id bigserial primary key, "+
+			"migration varchar(1024), event varchar(16) not null, direction varchar(8), "+
+			"recorded_at timestamptz not null default now(), duration_ms bigint, checksum varchar(64), "+
+			"previous_checksum varchar(64), db_user varchar(1024) not null default current_user, actor varchar(1024), "+
+			"app_version varchar(1024), error text)"); err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	// Tables created before checksum repairs were recorded
+	present, err := m.columnNames(ctx, tx, m.historyName())
+	if err != nil {
+		return err
+	}
+
+	if !present["previous_checksum"] {
+		if _, err := tx.ExecContext(ctx, "alter table "+m.historyTable()+" add column previous_checksum varchar(64)"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Appends the event to the migrations history.  The database user and time are filled in by the
+// database, and the actor and application version from the options.
+func (m *Migrator) recordHistory(ctx context.Context, conn execer, record HistoryRecord) error {
+	var duration any
+	if record.Duration > 0 {
+		duration = record.Duration.Milliseconds()
+	}
+
+	_, err := conn.ExecContext(ctx, "insert into "+m.historyTable()+" "+
+		"(migration, event, direction, duration_ms, checksum, previous_checksum, actor, app_version, error) "+
+		"values ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		nullable(record.Migration), string(record.Event), nullable(string(record.Direction)), duration,
+		nullable(record.Checksum), nullable(record.Previous), nullable(m.options.Actor), nullable(m.options.AppVersion),
+		nullable(record.Error))
+	return err
+}
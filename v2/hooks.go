@@ -0,0 +1,223 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// Callback SQL files in the migrations directory, run around the migrations as with Flyway.  Each
+// file is plain SQL, without "up" or "down" sections, and runs whichever direction the database
+// is migrating.  The files are optional.
+const (
+	// BeforeMigrate runs before any migrations are applied.
+	BeforeMigrate = "beforeMigrate.sql"
+
+	// BeforeEachMigrate runs before each migration, in the migration's transaction.
+	BeforeEachMigrate = "beforeEachMigrate.sql"
+
+	// AfterEachMigrate runs after each migration, in the migration's transaction.
+	AfterEachMigrate = "afterEachMigrate.sql"
+
+	// AfterMigrate runs after all the migrations are applied.
+	AfterMigrate = "afterMigrate.sql"
+)
+
+// Callbacks are the names of the callback SQL files, which are never treated as migrations.
+var callbacks = []string{BeforeMigrate, BeforeEachMigrate, AfterEachMigrate, AfterMigrate}
+
+// Hook is called around the migrations in the transaction tx.  The migration is the filename of
+// the migration being applied, or blank for BeforeAll and AfterAll.  Returning an error stops the
+// migrations, rolling back the transaction.
+type Hook func(ctx context.Context, tx *sql.Tx, migration string, direction Direction) error
+
+// ErrorHook is called when a migration fails, with the error returned by Apply.  The migration's
+// transaction has already been rolled back, so tx is a new transaction, committed once the hook
+// returns, e.g. to record the failure.
+type ErrorHook func(ctx context.Context, tx *sql.Tx, migration string, direction Direction, err error)
+
+// Hooks are Go callbacks run by Apply around the migrations, for example to re-grant privileges or
+// refresh materialized views once the schema changes.  Any of the hooks may be nil.  /async
+// migrations running in the background with ApplyAsync don't run BeforeEach or AfterEach.
+type Hooks struct {
+	// BeforeAll runs in its own transaction before any migrations are applied.
+	BeforeAll Hook
+
+	// BeforeEach runs before each migration, in the migration's transaction.
+	BeforeEach Hook
+
+	// AfterEach runs after each migration, in the migration's transaction.
+	AfterEach Hook
+
+	// AfterAll runs in its own transaction after all the migrations are applied.
+	AfterAll Hook
+
+	// OnError runs when a migration, a rollback, or one of the other hooks fails.
+	OnError ErrorHook
+}
+
+// Reads the callback SQL files present in the migrations directory.
+func (m *Migrator) loadCallbacks() (map[string]SQL, error) {
+	files, err := m.reader.Files(m.options.Directory)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]SQL)
+	for _, name := range files {
+		if !isCallback(name) {
+			continue
+		}
+
+		doc, err := m.readCallback(m.path(name))
+		if err != nil {
+			return nil, err
+		}
+
+		found[name] = doc
+	}
+
+	return found, nil
+}
+
+// Reads the callback SQL file, rendering it as a template if enabled.
+func (m *Migrator) readCallback(path string) (SQL, error) {
+	f, err := m.reader.Read(path)
+	if err != nil {
+		return "", err
+	}
+
+	if closer, ok := f.(io.Closer); ok {
+		defer func() {
+			_ = closer.Close()
+		}()
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s: %w", Filename(path), err)
+	}
+
+	if !m.options.Templates {
+		return SQL(data), nil
+	}
+
+	return m.render(path, SQL(data))
+}
+
+// Returns true if the file is one of the callback SQL files.
+func isCallback(name string) bool {
+	for _, callback := range callbacks {
+		if name == callback {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Runs the callback SQL file, if present, and then the Go hook, if any.
+func (m *Migrator) runHook(ctx context.Context, tx *sql.Tx, callback string, hook Hook, path string, direction Direction) error {
+	if doc, ok := m.callbacks[callback]; ok {
+		if _, err := tx.ExecContext(ctx, string(doc)); err != nil {
+			return fmt.Errorf("callback %s failed: %w", callback, err)
+		}
+	}
+
+	if hook == nil {
+		return nil
+	}
+
+	var migration string
+	if path != "" {
+		migration = Filename(path)
+	}
+
+	return hook(ctx, tx, migration, direction)
+}
+
+// Runs the callback SQL file and Go hook in their own transaction.
+func (m *Migrator) runHookTx(ctx context.Context, db Executor, callback string, hook Hook, path string, direction Direction) error {
+	if _, ok := m.callbacks[callback]; !ok && hook == nil {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := m.runHook(ctx, tx, callback, hook, path, direction); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Runs beforeMigrate.sql and the BeforeAll hook.
+func (m *Migrator) beforeAll(ctx context.Context, db Executor, direction Direction) error {
+	return m.runHookTx(ctx, db, BeforeMigrate, m.options.Hooks.BeforeAll, "", direction)
+}
+
+// Runs beforeEachMigrate.sql and the BeforeEach hook in the migration's transaction.
+func (m *Migrator) beforeEach(ctx context.Context, tx *sql.Tx, path string, direction Direction) error {
+	return m.runHook(ctx, tx, BeforeEachMigrate, m.options.Hooks.BeforeEach, path, direction)
+}
+
+// Runs afterEachMigrate.sql and the AfterEach hook in the migration's transaction.
+func (m *Migrator) afterEach(ctx context.Context, tx *sql.Tx, path string, direction Direction) error {
+	return m.runHook(ctx, tx, AfterEachMigrate, m.options.Hooks.AfterEach, path, direction)
+}
+
+// Runs afterMigrate.sql and the AfterAll hook.
+func (m *Migrator) afterAll(ctx context.Context, db Executor, direction Direction) error {
+	return m.runHookTx(ctx, db, AfterMigrate, m.options.Hooks.AfterAll, "", direction)
+}
+
+// Records the failure in the migrations history and calls the OnError hook, if any, then returns
+// the error.  ErrStopped isn't a failure, so is returned as is.
+func (m *Migrator) failed(db Executor, path string, direction Direction, err error) error {
+	if errors.Is(err, ErrStopped) {
+		return err
+	}
+
+	// The context may be cancelled, but the failure should still be recorded
+	ctx := context.Background()
+
+	var migration, sum string
+	if path != "" {
+		migration = Filename(path)
+		sum, _ = m.Checksum(path)
+	}
+
+	record := HistoryRecord{Migration: migration, Event: HistoryFailure, Direction: direction,
+		Checksum: sum, Error: err.Error()}
+	if historyErr := m.recordHistory(ctx, db, record); historyErr != nil {
+		m.log.Infof("Unable to record the failure in the migrations history: %s", historyErr)
+	}
+
+	hook := m.options.Hooks.OnError
+	if hook == nil {
+		return err
+	}
+
+	tx, txErr := db.BeginTx(ctx, nil)
+	if txErr != nil {
+		m.log.Infof("Unable to run the OnError hook: %s", txErr)
+		return err
+	}
+
+	hook(ctx, tx, migration, direction, err)
+
+	if txErr := tx.Commit(); txErr != nil {
+		m.log.Infof("Unable to commit the OnError hook: %s", txErr)
+	}
+
+	return err
+}
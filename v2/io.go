@@ -1,10 +1,19 @@
 package migrations
 
 import (
+	"errors"
 	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 )
 
+// ErrReadOnly returned if trying to create a migration in a read-only source, such as an embedded
+// file system.
+var ErrReadOnly = errors.New("migrations source is read-only")
+
 // Reader interface allows the migrations to be read from different sources,
 // such as an S3 bucket or another data store.
 type Reader interface {
@@ -15,6 +24,16 @@ type Reader interface {
 	Read(path string) (io.Reader, error)
 }
 
+// Writer may be implemented by a Reader that supports creating new migration files.  Readers
+// that don't implement Writer are read-only.
+type Writer interface {
+	// Write the migration file, creating the directory if necessary.
+	Write(path string, data []byte) error
+
+	// Rename the migration file.
+	Rename(from, to string) error
+}
+
 // DiskReader outputs to disk, the Migrations default.
 type DiskReader struct {
 }
@@ -38,3 +57,69 @@ func (d *DiskReader) Files(directory string) ([]string, error) {
 func (d *DiskReader) Read(path string) (io.Reader, error) {
 	return os.Open(path)
 }
+
+// Write the SQL migration to disk.
+func (d *DiskReader) Write(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// Rename the SQL migration on disk.  Fails rather than overwrite an existing file.
+func (d *DiskReader) Rename(from, to string) error {
+	if _, err := os.Stat(to); err == nil {
+		return fs.ErrExist
+	}
+
+	return os.Rename(from, to)
+}
+
+// FSReader reads migrations from an fs.FS, such as an embed.FS, so the SQL migration files may be
+// compiled into the application binary.  The FSReader is read-only.
+type FSReader struct {
+	fsys fs.FS
+}
+
+// NewFSReader reads migrations from the file system.  Directories are relative to the root of the
+// file system, so for example, with an embed.FS containing "sql/*.sql", use the "sql" directory,
+// or use fs.Sub to make the "sql" directory the root.
+func NewFSReader(fsys fs.FS) *FSReader {
+	return &FSReader{fsys: fsys}
+}
+
+// Files returns the names of the files in the directory, ignoring any sub-directories.
+func (r *FSReader) Files(directory string) ([]string, error) {
+	entries, err := fs.ReadDir(r.fsys, fsPath(directory))
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			paths = append(paths, entry.Name())
+		}
+	}
+
+	return paths, nil
+}
+
+// Read the SQL migration from the file system.
+func (r *FSReader) Read(path string) (io.Reader, error) {
+	return r.fsys.Open(fsPath(path))
+}
+
+// fsPath converts a local path, such as "./sql/1-create-users.sql" to the unrooted, slash-separated
+// form expected by fs.FS, e.g. "sql/1-create-users.sql".
+func fsPath(name string) string {
+	name = path.Clean(filepath.ToSlash(name))
+	name = strings.TrimPrefix(name, "/")
+
+	if name == "" {
+		return "."
+	}
+
+	return name
+}
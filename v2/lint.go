@@ -0,0 +1,398 @@
+package migrations
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Lint rule IDs, reported with each Finding and used to suppress findings.
+const (
+	// LintDropWithoutStop flags an "up" section that drops a table or column when the "down"
+	// section doesn't have a /stop modifier.  The rollback can recreate the table or column, but
+	// not the data that was in it.
+	LintDropWithoutStop = "drop-without-stop"
+
+	// LintIndexConcurrently flags a "create index" without "concurrently", which blocks writes
+	// to the table while the index is built.
+	LintIndexConcurrently = "index-concurrently"
+
+	// LintNotNullWithoutDefault flags a column added as "not null" without a default, which
+	// fails if the table has any rows.
+	LintNotNullWithoutDefault = "not-null-without-default"
+
+	// LintAlterColumnType flags changing the type of a column, which may rewrite the table
+	// while holding an exclusive lock.
+	LintAlterColumnType = "alter-column-type"
+
+	// LintEmptyDown flags a migration without any "down" SQL or a /stop modifier, so the
+	// rollback silently does nothing.
+	LintEmptyDown = "empty-down"
+
+	// LintMissingUp flags a migration file without a "--- !Up" marker, so nothing is applied.
+	LintMissingUp = "missing-up"
+)
+
+var (
+	// Matches a suppression comment, e.g. "-- lint:ignore index-concurrently, empty-down small table"
+	lintIgnoreRe = regexp.MustCompile(`--\s*lint:(ignore|file-ignore)\s+([\w-]+(?:\s*,\s*[\w-]+)*)`)
+
+	lintDropTableRe  = regexp.MustCompile(`(?is)^drop\s+table\b`)
+	lintAlterTableRe = regexp.MustCompile(`(?is)^alter\s+table\b`)
+	lintDropColumnRe = regexp.MustCompile(`(?is)^drop\s+(column\s+)?`)
+	lintIndexRe      = regexp.MustCompile(`(?is)^create\s+(unique\s+)?index\b`)
+	lintConcurrentRe = regexp.MustCompile(`(?is)^create\s+(unique\s+)?index\s+concurrently\b`)
+	lintAddColumnRe  = regexp.MustCompile(`(?is)^add\s+(column\s+)?`)
+	lintAddOtherRe   = regexp.MustCompile(`(?is)^add\s+(constraint|primary|unique|foreign|check|exclude)\b`)
+	lintNotNullRe    = regexp.MustCompile(`(?is)\bnot\s+null\b`)
+	lintDefaultRe    = regexp.MustCompile(`(?is)\b(default|generated)\b`)
+	lintColumnTypeRe = regexp.MustCompile(`(?is)^alter\s+(column\s+)?\S+\s+(set\s+data\s+)?type\b`)
+	lintDropOtherRe  = regexp.MustCompile(`(?is)^drop\s+(constraint|default|not\s+null|expression|identity)\b`)
+)
+
+// Finding is a risky pattern found in a migration file by Lint.
+type Finding struct {
+	File    string // The migration filename
+	Line    int    // The line in the file, starting at 1
+	Rule    string // The rule ID, e.g. LintIndexConcurrently
+	Message string // Describes the problem
+}
+
+// String formats the finding as "file:line: rule: message".
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", f.File, f.Line, f.Rule, f.Message)
+}
+
+// Lint checks the migration files in the directory for PostgreSQL operations that are risky to run
+// against a live database, such as building an index without "concurrently", and returns what it
+// finds, in file order.  Go migrations aren't checked.
+//
+// To suppress a finding, add a "-- lint:ignore <rule> [reason]" comment on the line above the
+// statement, or a "-- lint:file-ignore <rule> [reason]" comment anywhere in the file to suppress
+// the rule for the whole file.  Separate multiple rule IDs with commas.
+func Lint(options Options) ([]Finding, error) {
+	return options.migrator().Lint()
+}
+
+// Lint checks the migration files in the migrations directory for risky PostgreSQL operations.
+// See the package-level Lint.
+func (m *Migrator) Lint() ([]Finding, error) {
+	migrations, err := m.Available(Up)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for _, migration := range migrations {
+		if _, ok := m.funcs.lookup(migration); ok {
+			continue
+		}
+
+		found, err := m.lint(m.path(migration))
+		if err != nil {
+			return nil, fmt.Errorf("unable to lint %s: %w", migration, err)
+		}
+
+		findings = append(findings, found...)
+	}
+
+	return findings, nil
+}
+
+// A section of a migration file, with the line numbers of its statements.
+type lintSection struct {
+	marker     int   // The line of the "--- !Up" or "--- !Down" marker, or 0 if it's missing
+	statements []SQL // The statements in the section
+	lines      []int // The line each statement starts on
+	mods       Modifiers
+}
+
+// Checks a single migration file.
+func (m *Migrator) lint(path string) ([]Finding, error) {
+	filename := Filename(path)
+
+	lines, err := m.readLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	up, err := m.parseSection(path, lines, Up)
+	if err != nil {
+		return nil, err
+	}
+
+	down, err := m.parseSection(path, lines, Down)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	report := func(line int, rule, message string) {
+		findings = append(findings, Finding{File: filename, Line: line, Rule: rule, Message: message})
+	}
+
+	if up.marker == 0 {
+		report(1, LintMissingUp, `no "--- !Up" marker; nothing will be applied`)
+	}
+
+	if down.marker == 0 {
+		report(1, LintEmptyDown, `no "--- !Down" section; add the rollback SQL or a /stop modifier`)
+	} else if len(down.statements) == 0 && !down.mods.Has("/stop") {
+		report(down.marker, LintEmptyDown, "the Down section is empty; add the rollback SQL or a /stop modifier")
+	}
+
+	for idx, statement := range up.statements {
+		line := up.lines[idx]
+
+		for _, problem := range lintStatement(statement) {
+			if problem.Rule == LintDropWithoutStop && down.mods.Has("/stop") {
+				continue
+			}
+
+			report(line, problem.Rule, problem.Message)
+		}
+	}
+
+	for idx, statement := range down.statements {
+		line := down.lines[idx]
+
+		for _, problem := range lintStatement(statement) {
+			// Dropping what the "up" section created is what rollbacks do
+			if problem.Rule == LintDropWithoutStop {
+				continue
+			}
+
+			report(line, problem.Rule, problem.Message)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+
+	return suppress(lines, findings), nil
+}
+
+// Reads the statements in the up or down section of the migration, and works out which line of
+// the file each statement starts on.
+func (m *Migrator) parseSection(path string, lines []string, direction Direction) (lintSection, error) {
+	var section lintSection
+
+	end := len(lines)
+	for idx, line := range lines {
+		found := dirRe.FindStringSubmatch(line)
+		if len(found) != 2 {
+			continue
+		}
+
+		dir := strings.ToLower(strings.Split(found[1], " ")[0])
+		if section.marker > 0 {
+			end = idx
+			break
+		}
+
+		if Direction(dir) == direction {
+			section.marker = idx + 1
+		}
+	}
+
+	if section.marker == 0 {
+		return section, nil
+	}
+
+	doc, mods, err := m.ReadSQL(path, direction)
+	if err != nil {
+		return section, err
+	}
+
+	statements, err := ParseSQL(doc)
+	if err != nil {
+		return section, err
+	}
+
+	section.mods = mods
+	section.statements = statements
+
+	// Statements appear in file order, so search forward from the last one found.  If a statement
+	// can't be found, e.g. it was rendered from a template, report it on the last line found.
+	next, found := section.marker, section.marker
+	for _, statement := range statements {
+		first := strings.TrimSpace(strings.SplitN(string(statement), "\n", 2)[0])
+
+		for idx := next; idx < end; idx++ {
+			if strings.Contains(lines[idx], first) {
+				next, found = idx, idx+1
+				break
+			}
+		}
+
+		section.lines = append(section.lines, found)
+	}
+
+	return section, nil
+}
+
+// Returns the problems with a single statement, without the file and line.
+func lintStatement(statement SQL) []Finding {
+	sql := strings.TrimSpace(string(statement))
+
+	var problems []Finding
+	report := func(rule, message string) {
+		problems = append(problems, Finding{Rule: rule, Message: message})
+	}
+
+	switch {
+	case lintDropTableRe.MatchString(sql):
+		report(LintDropWithoutStop, "drops a table, but the Down section doesn't have a /stop modifier; "+
+			"the rollback can't restore the data")
+
+	case lintIndexRe.MatchString(sql):
+		if !lintConcurrentRe.MatchString(sql) {
+			report(LintIndexConcurrently, `creates an index without "concurrently", which blocks writes `+
+				"to the table; use \"create index concurrently\" with a /notx modifier")
+		}
+
+	case lintAlterTableRe.MatchString(sql):
+		for _, action := range alterActions(sql) {
+			switch {
+			case lintDropOtherRe.MatchString(action):
+				// Dropping a constraint or default doesn't lose any data
+
+			case lintDropColumnRe.MatchString(action):
+				report(LintDropWithoutStop, "drops a column, but the Down section doesn't have a /stop "+
+					"modifier; the rollback can't restore the data")
+
+			case lintAddOtherRe.MatchString(action):
+				// Adding a constraint, not a column
+
+			case lintAddColumnRe.MatchString(action):
+				if lintNotNullRe.MatchString(action) && !lintDefaultRe.MatchString(action) {
+					report(LintNotNullWithoutDefault, `adds a "not null" column without a default, `+
+						"which fails if the table has any rows")
+				}
+
+			case lintColumnTypeRe.MatchString(action):
+				report(LintAlterColumnType, "changes the type of a column, which may rewrite the table "+
+					"while locking out reads and writes")
+			}
+		}
+	}
+
+	return problems
+}
+
+// Splits an "alter table" statement into its comma-separated actions, e.g. "add column ...",
+// without the "alter table <name>" prefix.
+func alterActions(sql string) []string {
+	fields := strings.Fields(sql)
+
+	// Skip "alter table [if exists] [only] <name>"
+	skip := 2
+	for skip < len(fields) {
+		word := strings.ToLower(fields[skip])
+		if word == "if" || word == "exists" || word == "only" {
+			skip++
+			continue
+		}
+
+		skip++
+		break
+	}
+
+	if skip >= len(fields) {
+		return nil
+	}
+
+	body := strings.Join(fields[skip:], " ")
+
+	var actions []string
+	var depth int
+	var quoted bool
+	var start int
+
+	for idx, ch := range body {
+		switch {
+		case ch == '\'':
+			quoted = !quoted
+		case quoted:
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case ch == ',' && depth == 0:
+			actions = append(actions, strings.TrimSpace(body[start:idx]))
+			start = idx + 1
+		}
+	}
+
+	return append(actions, strings.TrimSpace(body[start:]))
+}
+
+// Removes the findings suppressed by a "lint:ignore" comment on the line above, or on the same
+// line as, the finding, or by a "lint:file-ignore" comment anywhere in the file.
+func suppress(lines []string, findings []Finding) []Finding {
+	fileIgnored := make(map[string]bool)
+	lineIgnored := make(map[int]map[string]bool)
+
+	for idx, line := range lines {
+		found := lintIgnoreRe.FindStringSubmatch(line)
+		if len(found) != 3 {
+			continue
+		}
+
+		for _, rule := range strings.Split(found[2], ",") {
+			rule = strings.TrimSpace(rule)
+
+			if found[1] == "file-ignore" {
+				fileIgnored[rule] = true
+				continue
+			}
+
+			// Covers the comment's own line and the line below it
+			for _, covered := range []int{idx + 1, idx + 2} {
+				if lineIgnored[covered] == nil {
+					lineIgnored[covered] = make(map[string]bool)
+				}
+
+				lineIgnored[covered][rule] = true
+			}
+		}
+	}
+
+	var results []Finding
+	for _, finding := range findings {
+		if fileIgnored[finding.Rule] || lineIgnored[finding.Line][finding.Rule] {
+			continue
+		}
+
+		results = append(results, finding)
+	}
+
+	return results
+}
+
+// Reads the lines of the migration file using the migrator's Reader.
+func (m *Migrator) readLines(path string) ([]string, error) {
+	f, err := m.reader.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if closer, ok := f.(io.Closer); ok {
+		defer func() {
+			_ = closer.Close()
+		}()
+	}
+
+	var lines []string
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+
+	return lines, s.Err()
+}
@@ -0,0 +1,160 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultLockKey is the PostgreSQL advisory lock key used to keep multiple instances of an
+	// application from migrating the database at the same time ("migrate" in ASCII).
+	DefaultLockKey int64 = 0x6d696772617465
+
+	// lockPollInterval is how often Lock retries the advisory lock when waiting with a timeout.
+	lockPollInterval = 250 * time.Millisecond
+)
+
+// ErrLockTimeout returned if another instance held the migrations lock longer than the lock
+// timeout.
+var ErrLockTimeout = errors.New("timed out waiting for the migrations lock")
+
+// Lock acquires the session-level PostgreSQL advisory lock identified by key.  The lock is held on
+// a dedicated connection, which is returned so the lock may be released with Unlock.  If timeout
+// is zero, waits indefinitely for the lock; otherwise returns ErrLockTimeout if the lock couldn't
+// be acquired in time.
+func Lock(db *sql.DB, key int64, timeout time.Duration) (*sql.Conn, error) {
+	return LockContext(context.Background(), db, key, timeout)
+}
+
+// LockContext acquires the advisory lock, as with Lock, but stops waiting if the context is
+// cancelled.
+func LockContext(ctx context.Context, db *sql.DB, key int64, timeout time.Duration) (*sql.Conn, error) {
+	return std().lock(ctx, db, key, timeout)
+}
+
+func (m *Migrator) lock(ctx context.Context, db *sql.DB, key int64, timeout time.Duration) (*sql.Conn, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.lockConn(ctx, conn, key, timeout); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Runs fn holding the migrations lock, unless the lock is disabled.  The lock is held on a
+// connection taken from the pool, so fn is passed that connection to run its work on; otherwise
+// a pool limited to a single connection would deadlock.  Without the lock, fn is passed db.
+func (m *Migrator) locked(ctx context.Context, db *sql.DB, fn func(conn Executor) error) error {
+	if !m.options.Lock {
+		return fn(db)
+	}
+
+	conn, err := m.lock(ctx, db, m.options.LockKey, m.options.LockTimeout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := Unlock(conn, m.options.LockKey); err != nil {
+			m.log.Infof("Unable to release the migrations lock: %s", err)
+		}
+	}()
+
+	return fn(conn)
+}
+
+// Acquires the advisory lock on the connection, waiting up to the timeout.
+func (m *Migrator) lockConn(ctx context.Context, conn *sql.Conn, key int64, timeout time.Duration) error {
+	if timeout <= 0 {
+		_, err := conn.ExecContext(ctx, "select pg_advisory_lock($1)", key)
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	waiting := false
+
+	for {
+		var locked bool
+
+		row := conn.QueryRowContext(ctx, "select pg_try_advisory_lock($1)", key)
+		if err := row.Scan(&locked); err != nil {
+			return err
+		}
+
+		if locked {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+
+		if !waiting {
+			m.log.Infof("Waiting for another instance to release the migrations lock")
+			waiting = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// Unlock releases the advisory lock acquired by Lock and closes the connection holding it.  The
+// lock is released even if the context used to acquire it was cancelled.
+func Unlock(conn *sql.Conn, key int64) error {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	_, err := conn.ExecContext(context.Background(), "select pg_advisory_unlock($1)", key)
+	return err
+}
+
+// ForceUnlock is an escape hatch for a stale migrations lock.  Advisory locks may only be released
+// by the session holding them, so ForceUnlock terminates any database sessions holding the lock
+// identified by key.  Returns the number of sessions terminated.
+func ForceUnlock(db *sql.DB, key int64) (int, error) {
+	return ForceUnlockContext(context.Background(), db, key)
+}
+
+// ForceUnlockContext terminates any database sessions holding the migrations lock, as with
+// ForceUnlock.
+func ForceUnlockContext(ctx context.Context, db *sql.DB, key int64) (int, error) {
+	// pg_locks splits the key into two unsigned oids, so take the halves without sign extension
+	classid, objid := uint64(key)>>32, uint64(key)&0xffffffff
+
+	rows, err := db.QueryContext(ctx, "select pg_terminate_backend(pid) from pg_locks "+
+		"where locktype = 'advisory' and granted and objsubid = 1 "+
+		"and classid::bigint = $1 and objid::bigint = $2 "+
+		"and database = (select oid from pg_database where datname = current_database())",
+		int64(classid), int64(objid))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var terminated int
+	for rows.Next() {
+		var ok bool
+		if err := rows.Scan(&ok); err != nil {
+			return terminated, err
+		}
+
+		if ok {
+			terminated++
+		}
+	}
+
+	return terminated, rows.Err()
+}
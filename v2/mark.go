@@ -0,0 +1,226 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownRevision returned if there's no migration with the revision.
+var ErrUnknownRevision = errors.New("no migration with the revision")
+
+// MarkApplied records the migration with the revision as applied, without running it, and stores
+// its "down" SQL in migrations.rollbacks.  Use it after applying a migration by hand, or to clear
+// a dirty /notx migration once it has been finished by hand.
+func MarkApplied(db *sql.DB, options Options, revision int64) error {
+	return MarkAppliedContext(context.Background(), db, options, revision)
+}
+
+// MarkAppliedContext records the migration with the revision as applied, as with MarkApplied.
+func MarkAppliedContext(ctx context.Context, db *sql.DB, options Options, revision int64) error {
+	return options.migrator().MarkAppliedContext(ctx, db, revision)
+}
+
+// Unmark removes the migration with the revision from migrations.applied and
+// migrations.rollbacks, without rolling it back.  Use it after rolling back a migration by hand,
+// or to clear a dirty /notx migration once it has been undone by hand.
+func Unmark(db *sql.DB, options Options, revision int64) error {
+	return UnmarkContext(context.Background(), db, options, revision)
+}
+
+// UnmarkContext removes the migration with the revision from the tracking tables, as with Unmark.
+func UnmarkContext(ctx context.Context, db *sql.DB, options Options, revision int64) error {
+	return options.migrator().UnmarkContext(ctx, db, revision)
+}
+
+// ForceRevision records the database as being at the revision, without running any migrations:
+// the migrations up to and including the revision are marked as applied, and any later
+// migrations are unmarked.  Any dirty migrations are cleared.
+func ForceRevision(db *sql.DB, options Options, revision int64) error {
+	return ForceRevisionContext(context.Background(), db, options, revision)
+}
+
+// ForceRevisionContext records the database as being at the revision, as with ForceRevision.
+func ForceRevisionContext(ctx context.Context, db *sql.DB, options Options, revision int64) error {
+	return options.migrator().ForceRevisionContext(ctx, db, revision)
+}
+
+// MarkApplied records the migration with the revision as applied, without running it.  See the
+// package-level MarkApplied.
+func (m *Migrator) MarkApplied(db *sql.DB, revision int64) error {
+	return m.MarkAppliedContext(context.Background(), db, revision)
+}
+
+// MarkAppliedContext records the migration with the revision as applied, as with MarkApplied.
+func (m *Migrator) MarkAppliedContext(ctx context.Context, db *sql.DB, revision int64) error {
+	return m.repairTx(ctx, db, func(tx *sql.Tx) error {
+		migration, err := m.availableRevision(revision)
+		if err != nil {
+			return err
+		}
+
+		marked, err := m.markApplied(ctx, tx, m.path(migration))
+		if err != nil {
+			return err
+		}
+
+		if !marked {
+			m.log.Infof("Migration %s is already applied", migration)
+		}
+
+		return nil
+	})
+}
+
+// Unmark removes the migration with the revision from the tracking tables, without rolling it
+// back.  See the package-level Unmark.
+func (m *Migrator) Unmark(db *sql.DB, revision int64) error {
+	return m.UnmarkContext(context.Background(), db, revision)
+}
+
+// UnmarkContext removes the migration with the revision from the tracking tables, as with Unmark.
+func (m *Migrator) UnmarkContext(ctx context.Context, db *sql.DB, revision int64) error {
+	return m.repairTx(ctx, db, func(tx *sql.Tx) error {
+		applied, err := m.AppliedContext(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range applied {
+			if rev, err := Revision(migration); err == nil && rev == revision {
+				return m.unmark(ctx, tx, migration)
+			}
+		}
+
+		return fmt.Errorf("%w %d applied", ErrUnknownRevision, revision)
+	})
+}
+
+// ForceRevision records the database as being at the revision, without running any migrations.
+// See the package-level ForceRevision.
+func (m *Migrator) ForceRevision(db *sql.DB, revision int64) error {
+	return m.ForceRevisionContext(context.Background(), db, revision)
+}
+
+// ForceRevisionContext records the database as being at the revision, as with ForceRevision.
+func (m *Migrator) ForceRevisionContext(ctx context.Context, db *sql.DB, revision int64) error {
+	return m.repairTx(ctx, db, func(tx *sql.Tx) error {
+		migrations, err := m.Available(Up)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if rev, err := Revision(migration); err != nil || !IsUp(rev, revision) {
+				continue
+			}
+
+			if _, err := m.markApplied(ctx, tx, m.path(migration)); err != nil {
+				return err
+			}
+		}
+
+		applied, err := m.AppliedContext(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range applied {
+			if rev, err := Revision(migration); err != nil || revision == Latest || !IsDown(rev, revision) {
+				continue
+			}
+
+			if err := m.unmark(ctx, tx, migration); err != nil {
+				return err
+			}
+		}
+
+		m.log.Infof("Forced the database to revision %d", revision)
+		return nil
+	})
+}
+
+// Runs the repair in a transaction, holding the migrations lock.
+func (m *Migrator) repairTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	return m.locked(ctx, db, func(conn Executor) error {
+		if err := m.checkDuplicates(); err != nil {
+			return err
+		}
+
+		if err := m.InitializeDBContext(ctx, conn); err != nil {
+			return err
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		return tx.Commit()
+	})
+}
+
+// Returns the available migration with the revision.
+func (m *Migrator) availableRevision(revision int64) (string, error) {
+	migrations, err := m.Available(Up)
+	if err != nil {
+		return "", err
+	}
+
+	for _, migration := range migrations {
+		if rev, err := Revision(migration); err == nil && rev == revision {
+			return migration, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w %d in %s", ErrUnknownRevision, revision, m.options.Directory)
+}
+
+// Records the migration as applied, replacing the placeholder left by a dirty /notx migration.
+// Returns false if the migration was already applied.
+func (m *Migrator) markApplied(ctx context.Context, tx *sql.Tx, path string) (bool, error) {
+	filename := Filename(path)
+
+	var dirty bool
+	row := tx.QueryRowContext(ctx, "select dirty from "+m.appliedTable()+" where migration = $1", filename)
+	if err := row.Scan(&dirty); err == nil && !dirty {
+		return false, nil
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	if dirty {
+		if _, err := tx.ExecContext(ctx, "delete from "+m.appliedTable()+" where migration = $1", filename); err != nil {
+			return false, err
+		}
+
+		m.log.Infof("Cleared dirty migration %s", filename)
+	}
+
+	if err := m.track(ctx, tx, HistoryRepair, path, Up, 0); err != nil {
+		return false, fmt.Errorf("unable to mark %s as applied: %w", filename, err)
+	}
+
+	m.log.Infof("Marked migration %s as applied", filename)
+	return true, nil
+}
+
+// Removes the migration from the tracking tables, without rolling it back.
+func (m *Migrator) unmark(ctx context.Context, tx *sql.Tx, migration string) error {
+	if err := m.track(ctx, tx, HistoryRepair, migration, Down, 0); err != nil {
+		return fmt.Errorf("unable to unmark %s: %w", migration, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "delete from "+m.asyncTable()+" where migration = $1", migration); err != nil {
+		return fmt.Errorf("unable to unmark %s: %w", migration, err)
+	}
+
+	m.log.Infof("Unmarked migration %s; it's no longer recorded as applied", migration)
+	return nil
+}
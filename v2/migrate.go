@@ -3,22 +3,32 @@ package migrations
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Direction is the direction to migrate
 type Direction string
 
 const (
+	// Version of the migrations package, recorded with each applied migration.
+	Version = "2.0.0"
+
 	// Latest migrates to the latest migration.
-	Latest int = -1
+	Latest int64 = -1
+
+	// TimestampFormat is the time layout for timestamp revisions, e.g. 20261017153000.
+	TimestampFormat = "20060102150405"
 
 	// Up direction.
 	Up Direction = "up"
@@ -47,33 +57,88 @@ type Queryable interface {
 	QueryRow(query string, args ...any) *sql.Row
 }
 
+// QueryableContext is implemented by database connections and transactions supporting a context,
+// such as *sql.DB, *sql.Conn, and *sql.Tx.
+type QueryableContext interface {
+	QueryContext(ctx context.Context, stmt string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Executor is implemented by database connections that run statements and begin transactions,
+// such as *sql.DB and *sql.Conn.  Pass a *sql.Conn to a Migrator to migrate using a particular
+// database session, e.g. with its own search_path.
+type Executor interface {
+	QueryableContext
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
 func init() {
 	IO = new(DiskReader)
 }
 
 // Create a new migration from the template.
 func Create(directory string, name string) error {
+	return WithDirectory(directory).migrator().Create(name)
+}
+
+// Create a new migration from the template in the options directory.  Returns ErrReadOnly if the
+// options read migrations from a read-only source, such as an embedded file system.
+func (options Options) Create(name string) error {
+	return options.migrator().Create(name)
+}
+
+// Create a new migration from the template in the migrations directory.  Returns ErrReadOnly if
+// the migrations are read from a read-only source, such as an embedded file system, or a
+// *DuplicateRevisionError if existing migration files share a revision number.
+func (m *Migrator) Create(name string) error {
 	trimmed := strings.TrimSpace(name)
 	if trimmed == "" {
 		return ErrNameRequired
 	}
 
-	if err := os.MkdirAll(directory, 0755); err != nil {
+	w, ok := m.reader.(Writer)
+	if !ok {
+		return ErrReadOnly
+	}
+
+	if err := m.checkDuplicates(); err != nil {
 		return err
 	}
 
-	r := LatestRevision(directory) + 1
-	fullname := fmt.Sprintf("%d-%s.sql", r, trimmed)
-	path := fmt.Sprintf("%s%c%s", directory, os.PathSeparator, fullname)
+	revision := m.nextRevision()
+	fullname := fmt.Sprintf("%d-%s.sql", revision, trimmed)
+	path := m.path(fullname)
 
-	if err := os.WriteFile(path, []byte("--- !Up\n\n--- !Down\n\n"), 0644); err != nil {
+	if err := w.Write(path, []byte("--- !Up\n\n--- !Down\n\n")); err != nil {
 		return err
 	}
 
-	Log.Infof("Created new migration %s", path)
+	m.log.Infof("Created new migration %s", path)
 	return nil
 }
 
+// Returns the revision for a new migration:  the current UTC time, e.g. 20261017153000, if the
+// options call for timestamps, otherwise the revision after the latest migration.  A timestamp
+// always sorts after the small, sequential revisions, so existing migrations keep their order.
+func (m *Migrator) nextRevision() int64 {
+	latest := m.LatestRevision()
+
+	if m.options.Timestamps {
+		revision, _ := strconv.ParseInt(time.Now().UTC().Format(TimestampFormat), 10, 64)
+		if revision > latest {
+			return revision
+		}
+	}
+
+	return latest + 1
+}
+
+// Returns the path to the migration file in the migrations directory.
+func (m *Migrator) path(migration string) string {
+	return fmt.Sprintf("%s%c%s", m.options.Directory, os.PathSeparator, migration)
+}
+
 // Apply any SQL migrations to the database using the default options.
 //
 // Any files that don't have entries in the migrations table will be run to bring the database to
@@ -87,6 +152,12 @@ func Apply(db *sql.DB) error {
 	return DefaultOptions().Apply(db)
 }
 
+// ApplyContext applies any SQL migrations to the database using the default options, stopping if
+// the context is cancelled.  See Options.ApplyContext.
+func ApplyContext(ctx context.Context, db *sql.DB) error {
+	return DefaultOptions().ApplyContext(ctx, db)
+}
+
 // Apply any SQL migrations to the database.
 //
 // Any files that don't have entries in the migrations table will be run to bring the database to
@@ -97,106 +168,301 @@ func Apply(db *sql.DB) error {
 //
 // If the migrations table does not exist, this function automatically creates it.
 //
+// Unless disabled, Apply holds a PostgreSQL advisory lock while migrating, so if multiple
+// instances of an application start at the same time, only one migrates the database and the
+// others wait for it to finish.
+//
 // May return an ErrStopped if rolling back migrations and the Down portion has a /stop modifier.
+// Returns a *DuplicateRevisionError without migrating if more than one migration file has the same
+// revision number.
 func (options Options) Apply(db *sql.DB) error {
-	if err := InitializeDB(db, options.Directory); err != nil {
+	return options.ApplyContext(context.Background(), db)
+}
+
+// ApplyContext applies any SQL migrations to the database, as with Apply, but stops if the context
+// is cancelled or its deadline passes.  The migration running at the time is rolled back, and the
+// error returned wraps ctx.Err() with the name of the migration.
+func (options Options) ApplyContext(ctx context.Context, db *sql.DB) error {
+	return options.migrator().ApplyContext(ctx, db)
+}
+
+// apply runs the migrations without locking.
+func (m *Migrator) apply(ctx context.Context, db Executor) error {
+	options := m.options
+
+	if err := m.checkDuplicates(); err != nil {
+		return err
+	}
+
+	if err := m.InitializeDBContext(ctx, db); err != nil {
 		return err
 	}
 
-	direction := Moving(db, options.Revision)
-	migrations, err := Available(options.Directory, direction)
+	if err := m.checkDirty(ctx, db); err != nil {
+		return err
+	}
+
+	// Stamp the migrations applied by this call with the same batch ID
+	batch, err := m.nextBatch(ctx, db)
 	if err != nil {
 		return err
 	}
+	m = m.inBatch(batch)
+
+	if m.callbacks, err = m.loadCallbacks(); err != nil {
+		return err
+	}
+
+	if err := m.checkChecksums(ctx, db, options.Checksums); err != nil {
+		return err
+	}
+
+	if err := m.checkRollbacks(ctx, db, options.RollbackDrift); err != nil {
+		return err
+	}
+
+	direction := m.moving(ctx, db, options.Revision)
+	if direction == Up {
+		if err := m.checkOrder(ctx, db, options.OutOfOrder); err != nil {
+			return err
+		}
+	}
 
+	migrations, err := m.Available(direction)
+	if err != nil {
+		return err
+	}
+
+	if err := m.beforeAll(ctx, db, direction); err != nil {
+		return m.failed(db, "", direction, err)
+	}
+
+	if options.SingleTx {
+		err = m.applySingleTx(ctx, db, direction, migrations)
+	} else {
+		err = m.applyEach(ctx, db, direction, migrations)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if options.EmbeddedRollbacks {
+		if err := m.handleEmbeddedRollbacks(ctx, db, options.Revision); err != nil {
+			return err
+		}
+	}
+
+	if err := m.afterAll(ctx, db, direction); err != nil {
+		return m.failed(db, "", direction, err)
+	}
+
+	return nil
+}
+
+// Applies each migration in its own transaction.
+func (m *Migrator) applyEach(ctx context.Context, db Executor, direction Direction, migrations []string) error {
 	for _, migration := range migrations {
-		path := fmt.Sprintf("%s%c%s", options.Directory, os.PathSeparator, migration)
+		if err := m.applyOne(ctx, db, migration, direction); err != nil {
+			return m.failed(db, m.path(migration), direction, err)
+		}
+	}
+
+	return nil
+}
 
-		tx, err := db.Begin()
+// Applies the migration in its own transaction, if it should run.
+func (m *Migrator) applyOne(ctx context.Context, db Executor, migration string, direction Direction) error {
+	path := m.path(migration)
+
+	if err := ctx.Err(); err != nil {
+		return interrupted(ctx, path, direction, err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return interrupted(ctx, path, direction, err)
+	}
+
+	if !m.shouldRun(ctx, tx, path, direction, m.options.Revision) {
+		if err = tx.Commit(); err != nil {
+			return interrupted(ctx, path, direction, err)
+		}
+
+		return nil
+	}
+
+	SQL, mods, err := m.ReadSQL(path, direction)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if direction == Down && mods.Has("/stop") {
+		m.log.Infof("Interrupting migrations due to /stop indicator in %s %s", path, direction)
+		_ = tx.Rollback()
+		return ErrStopped
+	}
+
+	if mods.Has("/async") {
+		req, skip, err := m.prepareAsync(ctx, tx, path, direction, SQL)
 		if err != nil {
-			return err
+			_ = tx.Rollback()
+			return interrupted(ctx, path, direction, err)
 		}
 
-		if ShouldRun(tx, path, direction, options.Revision) {
-			SQL, mods, err := ReadSQL(path, direction)
-			if err != nil {
-				_ = tx.Rollback()
-				return err
+		if skip {
+			if err := tx.Commit(); err != nil {
+				return interrupted(ctx, path, direction, err)
 			}
 
-			if direction == Down && mods.Has("/stop") {
-				Log.Infof("Interrupting migrations due to /stop indicator in %s %s", path, direction)
-				_ = tx.Rollback()
-				return ErrStopped
+			if req != nil {
+				m.async.requests <- *req
 			}
 
-			Log.Infof("Applying migration %s %s", path, direction)
+			return nil
+		}
+	}
 
-			_, err = tx.Exec(string(SQL))
-			if err != nil {
-				_ = tx.Rollback()
-				return err
-			}
+	if err := m.beforeEach(ctx, tx, path, direction); err != nil {
+		_ = tx.Rollback()
+		return interrupted(ctx, path, direction, err)
+	}
 
-			if err = Migrated(tx, path, direction); err != nil {
-				_ = tx.Rollback()
-				return err
-			}
+	if mods.Has("/notx") {
+		// The statements can't run in a transaction, but the hooks can
+		if err := tx.Commit(); err != nil {
+			return interrupted(ctx, path, direction, err)
 		}
 
-		if err = tx.Commit(); err != nil {
+		if err := m.applyNoTx(ctx, db, path, direction, SQL); err != nil {
 			return err
 		}
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return interrupted(ctx, path, direction, err)
+		}
+
+		if err := m.afterEach(ctx, tx, path, direction); err != nil {
+			_ = tx.Rollback()
+			return interrupted(ctx, path, direction, err)
+		}
+
+		return tx.Commit()
 	}
 
-	if !options.EmbeddedRollbacks {
-		return nil
+	m.log.Infof("Applying migration %s %s", path, direction)
+
+	start := time.Now()
+
+	if err := m.exec(ctx, tx, migration, direction, SQL); err != nil {
+		_ = tx.Rollback()
+		return interrupted(ctx, path, direction, err)
+	}
+
+	if err := m.migrated(ctx, tx, path, direction, time.Since(start)); err != nil {
+		_ = tx.Rollback()
+		return interrupted(ctx, path, direction, err)
+	}
+
+	if err := m.afterEach(ctx, tx, path, direction); err != nil {
+		_ = tx.Rollback()
+		return interrupted(ctx, path, direction, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return interrupted(ctx, path, direction, err)
+	}
+
+	return nil
+}
+
+// interrupted wraps ctx.Err() with the name of the migration that was running if the context was
+// cancelled.  Otherwise returns err.
+func interrupted(ctx context.Context, migration string, direction Direction, err error) error {
+	if ctx.Err() == nil {
+		return err
 	}
 
-	return HandleEmbeddedRollbacks(db, options.Directory, options.Revision)
+	return fmt.Errorf("migration %s %s interrupted: %w", Filename(migration), direction, ctx.Err())
 }
 
 // Rollback a number of migrations.  If steps is less than 2, rolls back the last migration.
 func Rollback(db *sql.DB, directory string, steps int) error {
+	return RollbackContext(context.Background(), db, directory, steps)
+}
+
+// RollbackContext rolls back a number of migrations, as with Rollback, but stops if the context is
+// cancelled.
+func RollbackContext(ctx context.Context, db *sql.DB, directory string, steps int) error {
+	return WithDirectory(directory).migrator().RollbackContext(ctx, db, steps)
+}
+
+// Rollback a number of migrations.  If steps is less than 2, rolls back the last migration.
+func (m *Migrator) Rollback(db *sql.DB, steps int) error {
+	return m.RollbackContext(context.Background(), db, steps)
+}
+
+// RollbackContext rolls back a number of migrations, as with Rollback, but stops if the context is
+// cancelled.
+func (m *Migrator) RollbackContext(ctx context.Context, db *sql.DB, steps int) error {
 	if steps < 2 {
 		steps = 1
 	}
 
-	latest, err := LatestMigration(db)
+	applied, err := m.AppliedContext(ctx, db)
 	if err != nil {
 		return err
 	}
 
-	revision, err := Revision(latest)
-	if err != nil {
-		return err
-	}
+	// Count back through the applied migrations, since revisions may not be sequential, e.g.
+	// timestamps
+	sort.Sort(SortDown(applied))
 
-	version := revision - steps
-	if version < 0 {
-		version = 0
+	var version int64
+	if steps < len(applied) {
+		version, err = Revision(applied[steps])
+		if err != nil {
+			return err
+		}
 	}
 
-	return Migrate(db, directory, version)
+	return m.with(m.options.WithRevision(version)).ApplyContext(ctx, db)
 }
 
 // Available returns the list of SQL migration paths in order.  If direction is
 // Down, returns the migrations in reverse order (migrating down).
 func Available(directory string, direction Direction) ([]string, error) {
-	files, err := IO.Files(directory)
-	if os.IsNotExist(err) {
-		return nil, nil
-	} else if err != nil {
+	return WithDirectory(directory).migrator().Available(direction)
+}
+
+// Available returns the list of SQL migration paths in the options directory in order.
+func (options Options) Available(direction Direction) ([]string, error) {
+	return options.migrator().Available(direction)
+}
+
+// Available returns the list of SQL migration paths in the migrations directory, merged with the
+// registered Go migrations, in order.  If direction is Down, returns the migrations in reverse
+// order (migrating down).
+func (m *Migrator) Available(direction Direction) ([]string, error) {
+	directory := m.options.Directory
+
+	files, err := m.reader.Files(directory)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return nil, fmt.Errorf("invalid migrations directory, %s: %s", directory, err.Error())
 	}
 
 	var filenames []string
 	for _, name := range files {
-		if strings.HasSuffix(name, ".sql") {
+		if strings.HasSuffix(name, ".sql") && !isCallback(name) {
 			filenames = append(filenames, name)
 		}
 	}
 
+	// Merge in the Go migrations
+	filenames = append(filenames, m.funcs.Migrations()...)
+
 	if direction == Down {
 		sort.Sort(SortDown(filenames))
 	} else {
@@ -208,10 +474,22 @@ func Available(directory string, direction Direction) ([]string, error) {
 
 // LatestRevision returns the latest revision available from the SQL files in
 // the migrations directory.
-func LatestRevision(directory string) int {
-	migrations, err := Available(directory, Down)
+func LatestRevision(directory string) int64 {
+	return WithDirectory(directory).migrator().LatestRevision()
+}
+
+// LatestRevision returns the latest revision available from the SQL files in the options
+// directory.
+func (options Options) LatestRevision() int64 {
+	return options.migrator().LatestRevision()
+}
+
+// LatestRevision returns the latest revision available from the SQL files in the migrations
+// directory.
+func (m *Migrator) LatestRevision() int64 {
+	migrations, err := m.Available(Down)
 	if err != nil {
-		Log.Infof(err.Error())
+		m.log.Infof(err.Error())
 		return 0
 	}
 
@@ -223,7 +501,7 @@ func LatestRevision(directory string) int {
 	for _, filename := range migrations {
 		rev, err := Revision(filename)
 		if err != nil {
-			Log.Infof("Invalid migration %s: %s", filename, err)
+			m.log.Infof("Invalid migration %s: %s", filename, err)
 			continue
 		}
 
@@ -233,14 +511,15 @@ func LatestRevision(directory string) int {
 	return 0
 }
 
-// Revision extracts the revision number from a migration filename.
-func Revision(filename string) (int, error) {
+// Revision extracts the revision number from a migration filename.  Revisions are 64-bit, so
+// timestamp revisions such as 20261017153000 sort after sequential revisions such as 1, 2, 3.
+func Revision(filename string) (int64, error) {
 	segments := strings.SplitN(Filename(filename), "-", 2)
 	if len(segments) == 1 {
 		return 0, fmt.Errorf("invalid migration filename: %s", filename)
 	}
 
-	v, err := strconv.Atoi(segments[0])
+	v, err := strconv.ParseInt(segments[0], 10, 64)
 	if err != nil {
 		return 0, err
 	}
@@ -255,14 +534,18 @@ func Filename(path string) string {
 }
 
 // Moving determines the direction we're moving to reach the version.
-func Moving(db *sql.DB, version int) Direction {
+func Moving(db *sql.DB, version int64) Direction {
+	return std().moving(context.Background(), db, version)
+}
+
+func (m *Migrator) moving(ctx context.Context, db QueryableContext, version int64) Direction {
 	if version == Latest {
 		return Up
 	}
 
-	latest, err := LatestMigration(db)
+	latest, err := m.LatestMigrationContext(ctx, db)
 	if err != nil {
-		Log.Infof("Unable to get the latest migration: %s", err)
+		m.log.Infof("Unable to get the latest migration: %s", err)
 		return None
 	}
 
@@ -272,7 +555,7 @@ func Moving(db *sql.DB, version int) Direction {
 
 	revision, err := Revision(latest)
 	if err != nil {
-		Log.Infof("Invalid result from revision: %s", err)
+		m.log.Infof("Invalid result from revision: %s", err)
 		return None
 	}
 
@@ -287,30 +570,34 @@ func Moving(db *sql.DB, version int) Direction {
 
 // ShouldRun decides if the migration should be applied or removed, based on
 // the direction and desired version to reach.
-func ShouldRun(tx *sql.Tx, migration string, direction Direction, desiredVersion int) bool {
+func ShouldRun(tx *sql.Tx, migration string, direction Direction, desiredVersion int64) bool {
+	return std().shouldRun(context.Background(), tx, migration, direction, desiredVersion)
+}
+
+func (m *Migrator) shouldRun(ctx context.Context, tx *sql.Tx, migration string, direction Direction, desiredVersion int64) bool {
 	version, err := Revision(migration)
 	if err != nil {
-		Log.Debugf("Unable to determine the revision of %s", migration)
+		m.log.Debugf("Unable to determine the revision of %s", migration)
 		return false
 	}
 
 	switch direction {
 	case Up:
-		return IsUp(version, desiredVersion) && !IsMigrated(tx, migration)
+		return IsUp(version, desiredVersion) && !m.isMigrated(ctx, tx, migration)
 	case Down:
-		return IsDown(version, desiredVersion) && IsMigrated(tx, migration)
+		return IsDown(version, desiredVersion) && m.isMigrated(ctx, tx, migration)
 	}
 	return false
 }
 
 // IsUp returns true if the migration must roll up to reach the desired version.
-func IsUp(version int, desired int) bool {
+func IsUp(version int64, desired int64) bool {
 	return desired == Latest || version <= desired
 }
 
 // IsDown returns true if the migration must rollback to reach the desired
 // version.
-func IsDown(version int, desired int) bool {
+func IsDown(version int64, desired int64) bool {
 	return version > desired
 }
 
@@ -348,11 +635,38 @@ func (m Modifiers) Has(value string) bool {
 
 // ReadSQL reads the migration and filters for the up or down SQL commands.
 func ReadSQL(path string, direction Direction) (SQL, Modifiers, error) {
-	f, err := IO.Read(path)
+	return std().ReadSQL(path, direction)
+}
+
+// ReadSQL reads the migration using the migrator's Reader and filters for the up or down SQL
+// commands.  If the options enable templates, the SQL is rendered with the template variables.
+func (m *Migrator) ReadSQL(path string, direction Direction) (SQL, Modifiers, error) {
+	SQL, mods, err := m.readSQL(path, direction)
+	if err != nil || !m.options.Templates {
+		return SQL, mods, err
+	}
+
+	rendered, err := m.render(path, SQL)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return rendered, mods, nil
+}
+
+// Reads the up or down SQL commands from the migration, as is, without rendering any templates.
+func (m *Migrator) readSQL(path string, direction Direction) (SQL, Modifiers, error) {
+	f, err := m.reader.Read(path)
 	if err != nil {
 		return "", nil, nil
 	}
 
+	if closer, ok := f.(io.Closer); ok {
+		defer func() {
+			_ = closer.Close()
+		}()
+	}
+
 	sqldoc := new(bytes.Buffer)
 	parsing := false
 
@@ -396,11 +710,26 @@ func ReadSQL(path string, direction Direction) (SQL, Modifiers, error) {
 
 // LatestMigration returns the name of the latest migration run against the database.
 func LatestMigration(conn Queryable) (string, error) {
+	return std().LatestMigration(conn)
+}
+
+// LatestMigrationContext returns the name of the latest migration run against the database.
+func LatestMigrationContext(ctx context.Context, conn QueryableContext) (string, error) {
+	return std().LatestMigrationContext(ctx, conn)
+}
+
+// LatestMigration returns the name of the latest migration run against the database.
+func (m *Migrator) LatestMigration(conn Queryable) (string, error) {
+	return m.LatestMigrationContext(context.Background(), withContext(conn))
+}
+
+// LatestMigrationContext returns the name of the latest migration run against the database.
+func (m *Migrator) LatestMigrationContext(ctx context.Context, conn QueryableContext) (string, error) {
 	var latest, migration string
 
 	// PostgreSQL may not order the migrations by revision, so we need to compute which is
 	// latest
-	rows, err := conn.Query("select migration from migrations.applied")
+	rows, err := conn.QueryContext(ctx, "select migration from "+m.appliedTable())
 	if err != nil {
 		return "", err
 	}
@@ -426,7 +755,22 @@ func LatestMigration(conn Queryable) (string, error) {
 
 // Applied returns the list of migrations that have already been applied to this database.
 func Applied(conn Queryable) ([]string, error) {
-	rows, err := conn.Query("select migration from migrations.applied")
+	return std().Applied(conn)
+}
+
+// AppliedContext returns the list of migrations that have already been applied to this database.
+func AppliedContext(ctx context.Context, conn QueryableContext) ([]string, error) {
+	return std().AppliedContext(ctx, conn)
+}
+
+// Applied returns the list of migrations that have already been applied to this database.
+func (m *Migrator) Applied(conn Queryable) ([]string, error) {
+	return m.AppliedContext(context.Background(), withContext(conn))
+}
+
+// AppliedContext returns the list of migrations that have already been applied to this database.
+func (m *Migrator) AppliedContext(ctx context.Context, conn QueryableContext) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, "select migration from "+m.appliedTable())
 	if err != nil {
 		return nil, err
 	}
@@ -451,70 +795,140 @@ func Applied(conn Queryable) ([]string, error) {
 // IsMigrated checks the migration has been applied to the database, i.e. is it
 // in the migrations.applied table?
 func IsMigrated(tx *sql.Tx, migration string) bool {
-	row := tx.QueryRow("select migration from migrations.applied where migration = $1 limit 1 for update", Filename(migration))
+	return std().isMigrated(context.Background(), tx, migration)
+}
+
+func (m *Migrator) isMigrated(ctx context.Context, tx *sql.Tx, migration string) bool {
+	row := tx.QueryRowContext(ctx, "select migration from "+m.appliedTable()+" where migration = $1 limit 1 for update", Filename(migration))
 	return row.Scan() != sql.ErrNoRows
 }
 
 // Migrated adds or removes the migration record from migrations.applied.
 func Migrated(tx *sql.Tx, path string, direction Direction) error {
+	return std().migrated(context.Background(), tx, path, direction, 0)
+}
+
+// Adds or removes the migration record from migrations.applied, and records the change in the
+// migrations history.  Migrations applied "up" are recorded with the time they took to run, the
+// database user, and the actor and application version from the options.
+func (m *Migrator) migrated(ctx context.Context, tx *sql.Tx, path string, direction Direction, duration time.Duration) error {
+	event := HistoryApply
+	if direction == Down {
+		event = HistoryRollback
+	}
+
+	return m.track(ctx, tx, event, path, direction, duration)
+}
+
+// Adds or removes the migration record from migrations.applied, and records the event in the
+// migrations history.
+func (m *Migrator) track(ctx context.Context, tx *sql.Tx, event HistoryEvent, path string, direction Direction, duration time.Duration) error {
 	filename := Filename(path)
 
+	var sum string
 	if direction == Down {
-		if _, err := tx.Exec("delete from migrations.applied where migration = $1", filename); err != nil {
+		row := tx.QueryRowContext(ctx, "delete from "+m.appliedTable()+" where migration = $1 "+
+			"returning coalesce(checksum, '')", filename)
+		if err := row.Scan(&sum); err != nil && !errors.Is(err, sql.ErrNoRows) {
 			return err
 		}
 
-		if _, err := tx.Exec("delete from migrations.rollbacks where migration = $1", filename); err != nil {
+		if _, err := tx.ExecContext(ctx, "delete from "+m.rollbacksTable()+" where migration = $1", filename); err != nil {
 			return err
 		}
 	} else {
-		if _, err := tx.Exec("insert into migrations.applied (migration) values ($1)", filename); err != nil {
+		var err error
+		if sum, err = m.Checksum(path); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "insert into "+m.appliedTable()+" "+
+			"(migration, checksum, applied_at, duration_ms, applied_by, actor, app_version, library_version, batch) "+
+			"values ($1, $2, now(), $3, current_user, $4, $5, $6, $7)",
+			filename, nullable(sum), duration.Milliseconds(), nullable(m.options.Actor),
+			nullable(m.options.AppVersion), Version, nullableBatch(m.batch)); err != nil {
 			return err
 		}
 
-		if err := UpdateRollback(tx, path); err != nil {
+		if err := m.updateRollback(ctx, tx, path); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return m.recordHistory(ctx, tx, HistoryRecord{Migration: filename, Event: event, Direction: direction,
+		Duration: duration, Checksum: sum})
 }
 
 // InitializeDB prepares the tables in the database required to manage migrations.
 func InitializeDB(db *sql.DB, directory string) error {
-	tx, err := db.Begin()
+	return InitializeDBContext(context.Background(), db, directory)
+}
+
+// InitializeDBContext prepares the tables in the database required to manage migrations.
+func InitializeDBContext(ctx context.Context, db *sql.DB, directory string) error {
+	return WithDirectory(directory).migrator().InitializeDBContext(ctx, db)
+}
+
+// InitializeDB prepares the tables in the database required to manage migrations.
+func (m *Migrator) InitializeDB(db Executor) error {
+	return m.InitializeDBContext(context.Background(), db)
+}
+
+// InitializeDBContext prepares the tables in the database required to manage migrations.
+func (m *Migrator) InitializeDBContext(ctx context.Context, db Executor) error {
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	if err := CreateMigrationsSchema(tx); err != nil {
+	if err := m.createMigrationsSchema(ctx, tx); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
-	if err := CreateMigrationsApplied(tx); err != nil {
+	if err := m.createMigrationsApplied(ctx, tx); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
-	if err := CreateMigrationsRollbacks(tx); err != nil {
+	if err := m.createMigrationsRollbacks(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := m.createMigrationsAsync(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := m.createMigrationsHistory(ctx, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := m.upgradeMigrationsApplied(ctx, tx); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
 	// This won't do anything if the database is already upgraded from migrations/v1
-	if err := Upgrade(tx, directory); err != nil {
+	if err := m.upgrade(ctx, tx); err != nil {
 		return err
 	}
 
 	return tx.Commit()
 }
 
-// CreateMigrationsSchema creates the "migrations" schema for storing the migrations state.
+// CreateMigrationsSchema creates the "migrations" schema for storing the migrations state.  Use a
+// Migrator with Options.WithTrackingSchema to create a different schema.
 func CreateMigrationsSchema(tx *sql.Tx) error {
-	if MissingMigrationsSchema(tx) {
-		Log.Infof("Creating migrations schema in the database")
-		if _, err := tx.Exec("create schema migrations"); err != nil {
+	return std().createMigrationsSchema(context.Background(), tx)
+}
+
+func (m *Migrator) createMigrationsSchema(ctx context.Context, tx *sql.Tx) error {
+	if m.missingMigrationsSchema(ctx, tx) {
+		m.log.Infof("Creating %s schema in the database", m.schemaName())
+		if _, err := tx.ExecContext(ctx, "create schema "+m.trackingSchema()); err != nil {
 			return err
 		}
 	}
@@ -524,7 +938,11 @@ func CreateMigrationsSchema(tx *sql.Tx) error {
 
 // MissingMigrationsSchema returns true if there's no "migrations" schema in the database.
 func MissingMigrationsSchema(tx *sql.Tx) bool {
-	row := tx.QueryRow("SELECT not exists(select schema_name FROM information_schema.schemata WHERE schema_name = 'migrations')")
+	return std().missingMigrationsSchema(context.Background(), tx)
+}
+
+func (m *Migrator) missingMigrationsSchema(ctx context.Context, tx *sql.Tx) bool {
+	row := tx.QueryRowContext(ctx, "SELECT not exists(select schema_name FROM information_schema.schemata WHERE schema_name = $1)", m.schemaName())
 
 	var result bool
 	if err := row.Scan(&result); err != nil {
@@ -537,9 +955,14 @@ func MissingMigrationsSchema(tx *sql.Tx) bool {
 // CreateMigrationsApplied creates the migrations.applied table in the database if it doesn't
 // already exist.
 func CreateMigrationsApplied(tx *sql.Tx) error {
-	if MissingMigrationsApplied(tx) {
-		Log.Infof("Creating migrations.applied table in the database")
-		if _, err := tx.Exec("create table migrations.applied(migration varchar(1024) not null primary key)"); err != nil {
+	return std().createMigrationsApplied(context.Background(), tx)
+}
+
+func (m *Migrator) createMigrationsApplied(ctx context.Context, tx *sql.Tx) error {
+	if m.missingMigrationsApplied(ctx, tx) {
+		m.log.Infof("Creating %s.%s table in the database", m.schemaName(), m.appliedName())
+		if _, err := tx.ExecContext(ctx, "create table "+m.appliedTable()+"(migration varchar(1024) not null primary key, "+
+			strings.Join(appliedColumns, ", ")+")"); err != nil {
 			return err
 		}
 	}
@@ -549,15 +972,58 @@ func CreateMigrationsApplied(tx *sql.Tx) error {
 
 // MissingMigrationsApplied returns true if there is no migrations.applied table in the database.
 func MissingMigrationsApplied(tx *sql.Tx) bool {
-	row := tx.QueryRow("select not(exists(select 1 from pg_catalog.pg_class c " +
-		"join pg_catalog.pg_namespace n " +
-		"on n.oid = c.relnamespace " +
-		"where n.nspname = 'migrations' and c.relname = 'applied'))")
+	return std().missingMigrationsApplied(context.Background(), tx)
+}
 
-	var result bool
-	if err := row.Scan(&result); err != nil {
-		return true
+func (m *Migrator) missingMigrationsApplied(ctx context.Context, tx *sql.Tx) bool {
+	return m.missingTable(ctx, tx, m.appliedName())
+}
+
+// UpgradeMigrationsApplied adds any columns missing from a migrations.applied table created by an
+// earlier version of the migrations package.
+func UpgradeMigrationsApplied(tx *sql.Tx) error {
+	return std().upgradeMigrationsApplied(context.Background(), tx)
+}
+
+func (m *Migrator) upgradeMigrationsApplied(ctx context.Context, tx *sql.Tx) error {
+	present, err := m.appliedColumnNames(ctx, tx)
+	if err != nil {
+		return err
 	}
 
-	return result
+	// Altering the table requires an exclusive lock and ownership, so only when upgrading
+	for _, column := range appliedColumns {
+		if present[strings.Fields(column)[0]] {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "alter table "+m.appliedTable()+" add column if not exists "+column); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withContext returns the connection as a QueryableContext.  Connections not supporting a context
+// are wrapped, and the context is ignored.
+func withContext(conn Queryable) QueryableContext {
+	if qc, ok := conn.(QueryableContext); ok {
+		return qc
+	}
+
+	return queryable{conn}
+}
+
+// queryable adapts a Queryable to QueryableContext for backwards compatibility.
+type queryable struct {
+	conn Queryable
+}
+
+func (q queryable) QueryContext(_ context.Context, stmt string, args ...any) (*sql.Rows, error) {
+	return q.conn.Query(stmt, args...)
+}
+
+func (q queryable) QueryRowContext(_ context.Context, query string, args ...any) *sql.Row {
+	return q.conn.QueryRow(query, args...)
 }
@@ -0,0 +1,107 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Migrator applies migrations to a database using its own Reader, Logger, and Options, rather
+// than the package-level IO and Log.  Use separate migrators to migrate multiple databases from
+// different sources in the same process.  A Migrator is safe for concurrent use.
+//
+// The package-level functions, such as Apply and Rollback, use a default Migrator configured with
+// the IO and Log package variables.
+type Migrator struct {
+	options Options
+	reader  Reader
+	log     Logger
+	funcs   *Registry
+	async   *AsyncHandle
+	batch   int64
+
+	// The callback SQL files, such as afterMigrate.sql, loaded by Apply
+	callbacks map[string]SQL
+}
+
+// NewMigrator creates a migrator from the options.  If the options don't supply a Reader or
+// Logger, the migrator reads from disk and logs to stdout and stderr; it never uses the
+// package-level IO or Log.  Likewise, it only runs the Go migrations in the options Registry, not
+// those added with the package-level Register.
+func NewMigrator(options Options) *Migrator {
+	m := &Migrator{
+		options: options,
+		reader:  options.Reader,
+		log:     options.Logger,
+		funcs:   options.Registry,
+	}
+
+	if m.reader == nil {
+		m.reader = new(DiskReader)
+	}
+
+	if m.log == nil {
+		m.log = new(DefaultLogger)
+	}
+
+	return m
+}
+
+// Returns the migrator configured by the options, falling back on the package IO, Log, and Funcs
+// variables.
+func (options Options) migrator() *Migrator {
+	m := &Migrator{
+		options: options,
+		reader:  options.Reader,
+		log:     options.Logger,
+		funcs:   options.Registry,
+	}
+
+	if m.reader == nil {
+		m.reader = IO
+	}
+
+	if m.log == nil {
+		m.log = Log
+	}
+
+	if m.funcs == nil {
+		m.funcs = Funcs
+	}
+
+	return m
+}
+
+// Returns the default migrator, configured with the default options and the package IO and Log
+// variables.
+func std() *Migrator {
+	return DefaultOptions().migrator()
+}
+
+// Returns a copy of the migrator with different options, using the same Reader, Logger, and
+// Registry.
+func (m *Migrator) with(options Options) *Migrator {
+	return &Migrator{
+		options: options,
+		reader:  m.reader,
+		log:     m.log,
+		funcs:   m.funcs,
+	}
+}
+
+// Options returns the options used by the migrator.
+func (m *Migrator) Options() Options {
+	return m.options
+}
+
+// Apply any SQL migrations to the database.  See Options.Apply.
+func (m *Migrator) Apply(db *sql.DB) error {
+	return m.ApplyContext(context.Background(), db)
+}
+
+// ApplyContext applies any SQL migrations to the database, stopping if the context is cancelled.
+// See Options.ApplyContext.
+func (m *Migrator) ApplyContext(ctx context.Context, db *sql.DB) error {
+	return m.locked(ctx, db, func(conn Executor) error {
+		return m.apply(ctx, conn)
+	})
+}
@@ -0,0 +1,113 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Prefixes the down SQL stored in migrations.rollbacks for migrations with a /notx modifier on
+// the Down section, so the embedded rollbacks run it outside a transaction too.
+const notxRollback = "/notx\n"
+
+// ErrDirty returned if a migration with a /notx modifier failed partway, leaving the database
+// partially migrated.  Apply refuses to continue until the database is repaired by hand and the
+// migration's dirty flag in migrations.applied is cleared.  Use errors.As with a *DirtyError to
+// get the details.
+var ErrDirty = errors.New("database is dirty")
+
+// DirtyError describes a /notx migration that failed partway.
+type DirtyError struct {
+	Migration string    // The migration filename
+	Direction Direction // The direction the migration was running, if known
+	Statement SQL       // The statement that failed, if known
+	Err       error     // The error returned by the failed statement, if known
+}
+
+// Error describes the failed migration.
+func (e *DirtyError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %s failed partway and must be repaired", ErrDirty, e.Migration)
+	}
+
+	return fmt.Sprintf("%s: %s %s failed partway at %q: %s", ErrDirty, e.Migration, e.Direction,
+		e.Statement, e.Err)
+}
+
+// Is matches ErrDirty.
+func (e *DirtyError) Is(target error) bool {
+	return target == ErrDirty
+}
+
+// Unwrap returns the error from the failed statement.
+func (e *DirtyError) Unwrap() error {
+	return e.Err
+}
+
+// Returns a *DirtyError if a /notx migration failed partway on an earlier run.
+func (m *Migrator) checkDirty(ctx context.Context, db QueryableContext) error {
+	var migration string
+	row := db.QueryRowContext(ctx, "select migration from "+m.appliedTable()+" where dirty limit 1")
+	if err := row.Scan(&migration); errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return &DirtyError{Migration: migration}
+}
+
+// Runs each statement in a migration with a /notx modifier outside a transaction, for statements
+// such as "create index concurrently" that PostgreSQL won't run in one.  The migration is marked
+// dirty in migrations.applied while the statements run, so if one fails, the database is left
+// dirty and later migrations refuse to run until it's repaired.
+func (m *Migrator) applyNoTx(ctx context.Context, db Executor, path string, direction Direction, doc SQL) error {
+	filename := Filename(path)
+
+	commands, err := ParseSQL(doc)
+	if err != nil {
+		return err
+	}
+
+	if direction == Up {
+		_, err = db.ExecContext(ctx, "insert into "+m.appliedTable()+" (migration, dirty) values ($1, true)", filename)
+	} else {
+		_, err = db.ExecContext(ctx, "update "+m.appliedTable()+" set dirty = true where migration = $1", filename)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	m.log.Infof("Applying migration %s %s outside a transaction", path, direction)
+
+	start := time.Now()
+
+	for _, cmd := range commands {
+		if _, err := db.ExecContext(ctx, string(cmd)); err != nil {
+			return &DirtyError{Migration: filename, Direction: direction, Statement: cmd, Err: err}
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// Replace the dirty placeholder with the full record
+	if direction == Up {
+		if _, err := tx.ExecContext(ctx, "delete from "+m.appliedTable()+" where migration = $1", filename); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if err := m.migrated(ctx, tx, path, direction, time.Since(start)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -1,22 +1,135 @@
 package migrations
 
-import "os"
+import (
+	"io/fs"
+	"os"
+	"time"
+)
 
 // EnvMigrations is the environment variable that can be used to point to the directory of SQL
 // migrations.
 const EnvMigrations = "MIGRATIONS"
 
+// Policy determines how the migrations respond to a questionable situation, such as a migration
+// file that was modified after it was applied.
+type Policy int
+
+const (
+	// Error stops the migrations and returns an error.
+	Error Policy = iota
+
+	// Warn logs a warning and continues.
+	Warn
+
+	// Allow silently continues.
+	Allow
+)
+
 type Options struct {
 	// Revision is the revision to forcibly move to.  Defaults to the latest revision as
 	// indicated by the available SQL files (which could be a rollback if the applied
 	// migrations exceed the latest SQL file.
-	Revision int
+	Revision int64
 
 	// Directory is the directory containing the SQL files.  Defaults to the "./sql" directory.
 	Directory string
 
+	// Reader reads the SQL files from the directory.  Defaults to the package IO reader, which
+	// reads from disk.
+	Reader Reader
+
+	// Logger outputs the log messages.  Defaults to the package Log logger.
+	Logger Logger
+
+	// Registry holds the Go migrations to run alongside the SQL files.  Defaults to the package
+	// Funcs registry, used by Register.
+	Registry *Registry
+
+	// Actor identifies who or what applied the migrations, such as a deploy job, and is recorded
+	// in migrations.applied alongside the database user.  Optional.
+	Actor string
+
+	// AppVersion is the version of the application applying the migrations, recorded in
+	// migrations.applied.  Optional.
+	AppVersion string
+
+	// TrackingSchema is the schema holding the tables that track the migrations.  Defaults to
+	// "migrations".
+	TrackingSchema string
+
+	// AppliedTable is the table in the tracking schema recording the applied migrations.
+	// Defaults to "applied".
+	AppliedTable string
+
+	// RollbacksTable is the table in the tracking schema storing the rollbacks.  Defaults to
+	// "rollbacks".
+	RollbacksTable string
+
 	// EmbeddedRollbacks enables embedded rollbacks.  Defaults to true.
 	EmbeddedRollbacks bool
+
+	// Lock guards Apply with a PostgreSQL advisory lock, so only one instance of an application
+	// migrates the database at a time.  The lock is held on a connection taken from the pool, and
+	// the migrations run on that same connection.  Defaults to true.
+	Lock bool
+
+	// LockKey is the advisory lock key.  Defaults to DefaultLockKey.
+	LockKey int64
+
+	// LockTimeout is how long to wait for another instance to finish migrating before giving up
+	// with ErrLockTimeout.  Defaults to zero, waiting indefinitely.
+	LockTimeout time.Duration
+
+	// Checksums determines what happens if an applied migration file was modified.  Defaults to
+	// Error, which returns a *ChecksumError.
+	Checksums Policy
+
+	// OutOfOrder determines what happens if a migration arrives after migrations with higher
+	// revisions were applied.  Defaults to Warn, which logs the late migrations and applies
+	// them.  Error returns an *OutOfOrderError.
+	OutOfOrder Policy
+
+	// RefreshStale has Apply compare the "down" SQL stored in migrations.rollbacks against the
+	// Down section of each applied migration file, and replace any stored rollbacks that are out
+	// of sync, according to the RollbackDrift policy.  Defaults to false, keeping the rollback
+	// stored when the migration was applied.
+	RefreshStale bool
+
+	// RollbackDrift determines what happens if RefreshStale is set and a stored rollback is out of
+	// sync.  Defaults to Warn, which logs a diff of the change and refreshes the rollback.  Error
+	// returns a *RollbackDriftError, and Allow refreshes the rollback silently.
+	RollbackDrift Policy
+
+	// AsyncTimeout is how long an asynchronous migration may stay queued or running before it's
+	// assumed abandoned, e.g. because the process running it crashed, and is retried.  Set it
+	// longer than the slowest asynchronous migration takes to run.  Defaults to zero, never
+	// retrying them; use ResetAsync to retry one by hand.
+	AsyncTimeout time.Duration
+
+	// SingleTx applies all the pending migrations, along with their tracking and rollback
+	// bookkeeping, in a single transaction, so if one fails, none are applied.  Migrations with
+	// a /notx or /async modifier are rejected with ErrSingleTransaction.  Defaults to false,
+	// committing after each migration.
+	SingleTx bool
+
+	// Templates renders each section of the migration SQL through text/template before it's
+	// run, e.g. "alter table users owner to {{.Owner}}".  The variables are the environment
+	// variables, overridden by Vars.  Referencing an unknown variable is an error.  Defaults to
+	// false.
+	Templates bool
+
+	// Vars are the template variables, which take precedence over environment variables with
+	// the same name.
+	Vars map[string]string
+
+	// Timestamps names new migrations created with Create using the current UTC time as the
+	// revision, e.g. 20261017153000-add-users.sql, rather than the next sequential revision.
+	// Helps avoid revision collisions when several branches add migrations at once.
+	Timestamps bool
+
+	// Hooks are Go callbacks run around the migrations by Apply.  Callback SQL files in the
+	// migrations directory, such as afterMigrate.sql, run whether or not hooks are configured.
+	Hooks Hooks
 }
 
 // DefaultOptions returns the defaults for the migrations package.  Revision defaults to the
@@ -37,13 +150,21 @@ func DefaultOptions() Options {
 		Revision:          Latest,
 		Directory:         directory,
 		EmbeddedRollbacks: true,
+		Lock:              true,
+		LockKey:           DefaultLockKey,
+		Checksums:         Error,
+		OutOfOrder:        Warn,
+		RollbackDrift:     Warn,
+		TrackingSchema:    DefaultTrackingSchema,
+		AppliedTable:      DefaultAppliedTable,
+		RollbacksTable:    DefaultRollbacksTable,
 	}
 }
 
 // WithRevision manually indicates the revision to migrate the database to.  By default, the
 // migrations to get the database to the revision indicated by the latest SQL migraiton file is
 // used.
-func WithRevision(revision int) Options {
+func WithRevision(revision int64) Options {
 	return DefaultOptions().WithRevision(revision)
 }
 
@@ -59,10 +180,130 @@ func DisableEmbeddedRollbacks() Options {
 	return DefaultOptions().DisableEmbeddedRollbacks()
 }
 
+// WithFS reads the SQL migrations from the file system, such as an embed.FS, instead of from disk.
+// The directory is relative to the root of the file system.  For example:
+//
+//	//go:embed sql/*.sql
+//	var sqlFiles embed.FS
+//
+//	err := migrations.WithFS(sqlFiles).WithDirectory("sql").Apply(conn)
+func WithFS(fsys fs.FS) Options {
+	return DefaultOptions().WithFS(fsys)
+}
+
+// WithReader reads the SQL migrations using the reader, instead of from disk.
+func WithReader(reader Reader) Options {
+	return DefaultOptions().WithReader(reader)
+}
+
+// WithLogger outputs the log messages to the logger, instead of the package Log logger.
+func WithLogger(logger Logger) Options {
+	return DefaultOptions().WithLogger(logger)
+}
+
+// WithRegistry runs the Go migrations in the registry, instead of those in the package Funcs
+// registry.
+func WithRegistry(registry *Registry) Options {
+	return DefaultOptions().WithRegistry(registry)
+}
+
+// WithActor records who or what applied the migrations, such as the name of a deploy job.
+func WithActor(actor string) Options {
+	return DefaultOptions().WithActor(actor)
+}
+
+// WithAppVersion records the version of the application that applied the migrations.
+func WithAppVersion(version string) Options {
+	return DefaultOptions().WithAppVersion(version)
+}
+
+// WithTrackingSchema keeps track of the migrations in a different schema than "migrations".
+// Useful if multiple applications with separate migrations share a database.
+func WithTrackingSchema(name string) Options {
+	return DefaultOptions().WithTrackingSchema(name)
+}
+
+// WithTrackingTables changes the names of the tables in the tracking schema recording the applied
+// migrations and their rollbacks.
+func WithTrackingTables(applied, rollbacks string) Options {
+	return DefaultOptions().WithTrackingTables(applied, rollbacks)
+}
+
+// WithLockKey changes the PostgreSQL advisory lock key used to guard the migrations.  Useful if
+// multiple applications with separate migrations share a database.
+func WithLockKey(key int64) Options {
+	return DefaultOptions().WithLockKey(key)
+}
+
+// WithLockTimeout limits how long to wait for another instance to finish migrating the database.
+func WithLockTimeout(timeout time.Duration) Options {
+	return DefaultOptions().WithLockTimeout(timeout)
+}
+
+// DisableLock skips the advisory lock, e.g. if the database doesn't support advisory locks or the
+// application coordinates migrations itself.
+func DisableLock() Options {
+	return DefaultOptions().DisableLock()
+}
+
+// WithChecksumPolicy determines what happens if an applied migration file was modified.  By
+// default, Apply returns a *ChecksumError.
+func WithChecksumPolicy(policy Policy) Options {
+	return DefaultOptions().WithChecksumPolicy(policy)
+}
+
+// WithOutOfOrderPolicy determines what happens if a migration arrives after migrations with
+// higher revisions were applied.  By default, a warning is logged and the migration is applied.
+func WithOutOfOrderPolicy(policy Policy) Options {
+	return DefaultOptions().WithOutOfOrderPolicy(policy)
+}
+
+// RefreshRollbacks replaces any stored rollbacks that no longer match the Down section of the
+// migration files when applying the migrations, according to the policy.  See
+// Options.RefreshStale.
+func RefreshRollbacks(policy Policy) Options {
+	return DefaultOptions().RefreshRollbacks(policy)
+}
+
+// WithAsyncTimeout retries asynchronous migrations that have been queued or running for longer
+// than the timeout.  See Options.AsyncTimeout.
+func WithAsyncTimeout(timeout time.Duration) Options {
+	return DefaultOptions().WithAsyncTimeout(timeout)
+}
+
+// SingleTransaction applies all the pending migrations in a single transaction.  See
+// Options.SingleTx.
+func SingleTransaction() Options {
+	return DefaultOptions().SingleTransaction()
+}
+
+// WithVars renders the migration SQL as templates, using the variables and the environment.  See
+// Options.Templates.
+func WithVars(vars map[string]string) Options {
+	return DefaultOptions().WithVars(vars)
+}
+
+// WithTemplates renders the migration SQL as templates, using the environment variables.  See
+// Options.Templates.
+func WithTemplates() Options {
+	return DefaultOptions().WithTemplates()
+}
+
+// WithTimestamps names new migrations with a timestamp revision, e.g.
+// 20261017153000-add-users.sql, instead of the next sequential revision.
+func WithTimestamps() Options {
+	return DefaultOptions().WithTimestamps()
+}
+
+// WithHooks runs the Go callbacks around the migrations.  See Hooks.
+func WithHooks(hooks Hooks) Options {
+	return DefaultOptions().WithHooks(hooks)
+}
+
 // WithRevision manually indicates the revision to migrate the database to.  By default, the
 // migrations to get the database to the revision indicated by the latest SQL migraiton file is
 // used.
-func (options Options) WithRevision(revision int) Options {
+func (options Options) WithRevision(revision int64) Options {
 	options.Revision = revision
 	return options
 }
@@ -74,9 +315,160 @@ func (options Options) WithDirectory(path string) Options {
 	return options
 }
 
+// WithFS reads the SQL migrations from the file system, such as an embed.FS, instead of from disk.
+// The directory is relative to the root of the file system.
+func (options Options) WithFS(fsys fs.FS) Options {
+	options.Reader = NewFSReader(fsys)
+	return options
+}
+
+// WithReader reads the SQL migrations using the reader, instead of from disk.
+func (options Options) WithReader(reader Reader) Options {
+	options.Reader = reader
+	return options
+}
+
+// WithLogger outputs the log messages to the logger, instead of the package Log logger.
+func (options Options) WithLogger(logger Logger) Options {
+	options.Logger = logger
+	return options
+}
+
+// WithRegistry runs the Go migrations in the registry, instead of those in the package Funcs
+// registry.
+func (options Options) WithRegistry(registry *Registry) Options {
+	options.Registry = registry
+	return options
+}
+
+// WithActor records who or what applied the migrations, such as the name of a deploy job.
+func (options Options) WithActor(actor string) Options {
+	options.Actor = actor
+	return options
+}
+
+// WithAppVersion records the version of the application that applied the migrations.
+func (options Options) WithAppVersion(version string) Options {
+	options.AppVersion = version
+	return options
+}
+
+// WithTrackingSchema keeps track of the migrations in a different schema than "migrations".
+// Useful if multiple applications with separate migrations share a database.
+func (options Options) WithTrackingSchema(name string) Options {
+	options.TrackingSchema = name
+	return options
+}
+
+// WithTrackingTables changes the names of the tables in the tracking schema recording the applied
+// migrations and their rollbacks.  Defaults to "applied" and "rollbacks".
+func (options Options) WithTrackingTables(applied, rollbacks string) Options {
+	options.AppliedTable = applied
+	options.RollbacksTable = rollbacks
+	return options
+}
+
 // DisableEmbeddedRollbacks disables the embedded rollbacks functionality.  Rollbacks must be
 // triggered manually, using WithRevision.
 func (options Options) DisableEmbeddedRollbacks() Options {
 	options.EmbeddedRollbacks = false
 	return options
 }
+
+// WithLockKey changes the PostgreSQL advisory lock key used to guard the migrations.  Useful if
+// multiple applications with separate migrations share a database.
+func (options Options) WithLockKey(key int64) Options {
+	options.Lock = true
+	options.LockKey = key
+	return options
+}
+
+// WithLockTimeout limits how long to wait for another instance to finish migrating the database.
+func (options Options) WithLockTimeout(timeout time.Duration) Options {
+	options.LockTimeout = timeout
+	return options
+}
+
+// DisableLock skips the advisory lock, e.g. if the database doesn't support advisory locks or the
+// application coordinates migrations itself.
+func (options Options) DisableLock() Options {
+	options.Lock = false
+	return options
+}
+
+// WithChecksumPolicy determines what happens if an applied migration file was modified.  By
+// default, Apply returns a *ChecksumError.
+func (options Options) WithChecksumPolicy(policy Policy) Options {
+	options.Checksums = policy
+	return options
+}
+
+// WithOutOfOrderPolicy determines what happens if a migration arrives after migrations with
+// higher revisions were applied.  By default, a warning is logged and the migration is applied.
+func (options Options) WithOutOfOrderPolicy(policy Policy) Options {
+	options.OutOfOrder = policy
+	return options
+}
+
+// RefreshRollbacks replaces any stored rollbacks that no longer match the Down section of the
+// migration files when applying the migrations, e.g. after fixing a buggy rollback in a migration
+// that has already shipped.  Warn logs a diff of each change, Error stops Apply with a
+// *RollbackDriftError, and Allow refreshes the rollbacks silently.  See Options.RefreshStale.
+func (options Options) RefreshRollbacks(policy Policy) Options {
+	options.RefreshStale = true
+	options.RollbackDrift = policy
+	return options
+}
+
+// WithAsyncTimeout retries asynchronous migrations that have been queued or running for longer
+// than the timeout, assuming the process running them crashed.  See Options.AsyncTimeout.
+func (options Options) WithAsyncTimeout(timeout time.Duration) Options {
+	options.AsyncTimeout = timeout
+	return options
+}
+
+// SingleTransaction applies all the pending migrations in a single transaction, so a failed
+// migration doesn't leave the database with only part of a release applied.  See
+// Options.SingleTx.
+func (options Options) SingleTransaction() Options {
+	options.SingleTx = true
+	return options
+}
+
+// WithVars renders the migration SQL as templates, using the variables and the environment.
+// Adds to any variables already supplied.  See Options.Templates.
+func (options Options) WithVars(vars map[string]string) Options {
+	merged := make(map[string]string, len(options.Vars)+len(vars))
+	for name, value := range options.Vars {
+		merged[name] = value
+	}
+
+	for name, value := range vars {
+		merged[name] = value
+	}
+
+	options.Templates = true
+	options.Vars = merged
+	return options
+}
+
+// WithTemplates renders the migration SQL as templates, using the environment variables.  See
+// Options.Templates.
+func (options Options) WithTemplates() Options {
+	options.Templates = true
+	return options
+}
+
+// WithTimestamps names new migrations with a timestamp revision, e.g.
+// 20261017153000-add-users.sql, instead of the next sequential revision.
+func (options Options) WithTimestamps() Options {
+	options.Timestamps = true
+	return options
+}
+
+// WithHooks runs the Go callbacks around the migrations, such as re-granting privileges after
+// the migrations are applied.  See Hooks.
+func (options Options) WithHooks(hooks Hooks) Options {
+	options.Hooks = hooks
+	return options
+}
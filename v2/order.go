@@ -0,0 +1,112 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrOutOfOrder returned if a migration arrived after migrations with higher revisions were
+// applied, e.g. when branches are merged out of order.  Use errors.As with an *OutOfOrderError to
+// get the details.
+var ErrOutOfOrder = errors.New("migrations out of order")
+
+// OutOfOrderError lists the pending migrations with revisions lower than the latest applied
+// migration.
+type OutOfOrderError struct {
+	Latest     string   // The latest migration applied to the database
+	Migrations []string // The late-arriving migration filenames, in revision order
+	Revisions  []int64  // The revisions of the late-arriving migrations
+}
+
+// Error describes the late-arriving migrations.
+func (e *OutOfOrderError) Error() string {
+	return fmt.Sprintf("%s: %s arrived after %s was applied", ErrOutOfOrder,
+		strings.Join(e.Migrations, ", "), e.Latest)
+}
+
+// Is matches ErrOutOfOrder.
+func (e *OutOfOrderError) Is(target error) bool {
+	return target == ErrOutOfOrder
+}
+
+// Checks for pending migrations with lower revisions than the latest applied migration, that
+// would be applied on the way to the target revision, and responds according to the policy.
+func (m *Migrator) checkOrder(ctx context.Context, db QueryableContext, policy Policy) error {
+	if policy == Allow {
+		return nil
+	}
+
+	late, err := m.outOfOrder(ctx, db)
+	if err != nil || late == nil {
+		return err
+	}
+
+	if policy == Error {
+		return late
+	}
+
+	m.log.Infof("Warning: %s", late)
+	return nil
+}
+
+// Returns the details of any late-arriving migrations, or nil if the migrations are in order.
+func (m *Migrator) outOfOrder(ctx context.Context, db QueryableContext) (*OutOfOrderError, error) {
+	latest, err := m.LatestMigrationContext(ctx, db)
+	if err != nil || latest == "" {
+		return nil, err
+	}
+
+	latestRevision, err := Revision(latest)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.AppliedContext(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[string]bool)
+	for _, migration := range applied {
+		done[migration] = true
+	}
+
+	// Asynchronous migrations finish after later migrations, so they're never late
+	if !m.missingTable(ctx, db, m.asyncName()) {
+		async, err := m.pendingAsync(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+
+		for migration := range async {
+			done[migration] = true
+		}
+	}
+
+	migrations, err := m.Available(Up)
+	if err != nil {
+		return nil, err
+	}
+
+	var late OutOfOrderError
+	for _, migration := range migrations {
+		revision, err := Revision(migration)
+		if err != nil || done[migration] {
+			continue
+		}
+
+		if revision < latestRevision && IsUp(revision, m.options.Revision) {
+			late.Migrations = append(late.Migrations, migration)
+			late.Revisions = append(late.Revisions, revision)
+		}
+	}
+
+	if len(late.Migrations) == 0 {
+		return nil, nil
+	}
+
+	late.Latest = latest
+	return &late, nil
+}
@@ -0,0 +1,344 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Source indicates where the SQL for a migration step comes from.
+type Source string
+
+const (
+	// FromFile steps run SQL from a migration file.
+	FromFile Source = "file"
+
+	// FromRollback steps run "down" SQL stored in migrations.rollbacks.
+	FromRollback Source = "rollback"
+
+	// FromFunc steps run a registered Go migration, so have no SQL.
+	FromFunc Source = "func"
+
+	// FromCallback steps run a callback SQL file, such as beforeEachMigrate.sql.
+	FromCallback Source = "callback"
+)
+
+// Step is a single migration Apply would run against the database.
+type Step struct {
+	Migration string    // The migration or callback filename
+	Direction Direction // Up or Down
+	Revision  int64     // The revision number of the migration, or 0 for a callback
+	Modifiers Modifiers // Any modifiers on the migration's direction line, e.g. /stop
+	SQL       SQL       // The SQL that would be run
+	Source    Source    // FromFile, FromRollback, FromFunc, or FromCallback
+	Stop      bool      // Apply would stop at this step and return ErrStopped
+	Async     bool      // The migration has an /async modifier, so ApplyAsync would run it in the background
+}
+
+// String describes the step on a single line.
+func (s Step) String() string {
+	var mods string
+	if len(s.Modifiers) > 0 {
+		mods = " " + strings.Join(s.Modifiers, " ")
+	}
+
+	if s.Stop {
+		return fmt.Sprintf("stop %s%s (%s)", s.Migration, mods, s.Source)
+	}
+
+	return fmt.Sprintf("%s %s%s (%s)", s.Direction, s.Migration, mods, s.Source)
+}
+
+// Plan returns the steps Apply would take to migrate the database, in order, without changing the
+// database.  Uses the same logic as Apply to determine the direction and the migrations to run,
+// including any embedded rollbacks.  Apply's checks run first, so Plan returns the same error
+// Apply would for a dirty database, a modified migration file, a stale rollback, or a
+// late-arriving migration, according to the options.  If a rollback would be interrupted by a /stop modifier, the
+// last step has Stop set.  Callback SQL files, such as beforeEachMigrate.sql, appear as steps
+// where Apply would run them.  Migrations with an /async modifier that are queued or running in
+// another process are skipped, as Apply would skip them.
+//
+// Plan doesn't account for upgrading a migrations/v1 database, which Apply would do first.
+func (options Options) Plan(db *sql.DB) ([]Step, error) {
+	return options.PlanContext(context.Background(), db)
+}
+
+// PlanContext returns the steps Apply would take to migrate the database, as with Plan.
+func (options Options) PlanContext(ctx context.Context, db *sql.DB) ([]Step, error) {
+	return options.migrator().PlanContext(ctx, db)
+}
+
+// Plan returns the steps Apply would take to migrate the database, in order, without changing the
+// database.  See Options.Plan.
+func (m *Migrator) Plan(db Executor) ([]Step, error) {
+	return m.PlanContext(context.Background(), db)
+}
+
+// PlanContext returns the steps Apply would take to migrate the database, as with Plan.
+func (m *Migrator) PlanContext(ctx context.Context, db Executor) ([]Step, error) {
+	options := m.options
+
+	if err := m.checkDuplicates(); err != nil {
+		return nil, err
+	}
+
+	// Read-only, so the plan doesn't lock or change the tracking tables
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// On a new database, Apply creates empty migrations tables first
+	initialized := !m.missingMigrationsApplied(ctx, tx)
+
+	direction := Up
+	applied := make(map[string]bool)
+	tracksAsync := initialized && !m.missingTable(ctx, tx, m.asyncName())
+
+	var refreshed map[string]string
+	if initialized {
+		if refreshed, err = m.planChecks(ctx, tx); err != nil {
+			return nil, err
+		}
+
+		direction = m.moving(ctx, tx, options.Revision)
+
+		if direction == Up {
+			if err := m.checkOrder(ctx, tx, options.OutOfOrder); err != nil {
+				return nil, err
+			}
+		}
+
+		migrations, err := m.AppliedContext(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, migration := range migrations {
+			applied[migration] = true
+		}
+	}
+
+	migrations, err := m.Available(direction)
+	if err != nil {
+		return nil, err
+	}
+
+	callbacks, err := m.loadCallbacks()
+	if err != nil {
+		return nil, err
+	}
+
+	// Returns a step for the callback SQL file, if there is one
+	callback := func(name string) []Step {
+		doc, ok := callbacks[name]
+		if !ok {
+			return nil
+		}
+
+		return []Step{{Migration: name, Direction: direction, SQL: doc, Source: FromCallback}}
+	}
+
+	steps := callback(BeforeMigrate)
+	for _, migration := range migrations {
+		path := m.path(migration)
+
+		revision, err := Revision(migration)
+		if err != nil {
+			continue
+		}
+
+		// As with shouldRun, but without locking the applied migrations
+		switch direction {
+		case Up:
+			if !IsUp(revision, options.Revision) || applied[migration] {
+				continue
+			}
+		case Down:
+			if !IsDown(revision, options.Revision) || !applied[migration] {
+				continue
+			}
+		default:
+			continue
+		}
+
+		SQL, mods, err := m.ReadSQL(path, direction)
+		if err != nil {
+			return nil, err
+		}
+
+		step := Step{
+			Migration: migration,
+			Direction: direction,
+			Revision:  revision,
+			Modifiers: mods,
+			SQL:       SQL,
+			Source:    FromFile,
+		}
+
+		if _, ok := m.funcs.lookup(migration); ok {
+			step.Source = FromFunc
+		}
+
+		if direction == Down && mods.Has("/stop") {
+			step.Stop = true
+			return append(steps, step), nil
+		}
+
+		if mods.Has("/async") {
+			step.Async = true
+
+			if tracksAsync {
+				record, err := m.asyncRecord(ctx, tx, migration, false)
+				if err != nil {
+					return nil, err
+				}
+
+				if m.asyncBusy(record) {
+					continue
+				}
+			}
+		}
+
+		steps = append(steps, callback(BeforeEachMigrate)...)
+		steps = append(steps, step)
+		steps = append(steps, callback(AfterEachMigrate)...)
+		applied[migration] = direction == Up
+	}
+
+	if options.EmbeddedRollbacks && initialized {
+		rollbacks, err := m.planRollbacks(ctx, tx, options.Revision, applied, refreshed)
+		if err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, rollbacks...)
+
+		if len(rollbacks) > 0 && rollbacks[len(rollbacks)-1].Stop {
+			return steps, nil
+		}
+	}
+
+	return append(steps, callback(AfterMigrate)...), nil
+}
+
+// Plan returns the steps Apply would take to migrate the database using the default options.
+func Plan(db *sql.DB) ([]Step, error) {
+	return DefaultOptions().Plan(db)
+}
+
+// Runs the checks Apply makes before migrating, without changing the database, returning the same
+// errors.  Returns the "down" SQL Apply would refresh in migrations.rollbacks first, mapped by
+// migration filename.  Columns missing from a migrations.applied table created by an earlier
+// release skip their checks, since Apply adds them empty.
+func (m *Migrator) planChecks(ctx context.Context, tx *sql.Tx) (map[string]string, error) {
+	present, err := m.appliedColumnNames(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if present["dirty"] {
+		if err := m.checkDirty(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	if present["checksum"] {
+		if err := m.verifyChecksums(ctx, tx, m.options.Checksums); err != nil {
+			return nil, err
+		}
+	}
+
+	if !m.options.RefreshStale {
+		return nil, nil
+	}
+
+	stale, err := m.driftedRollbacks(ctx, tx, m.options.RollbackDrift)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed := make(map[string]string, len(stale))
+	for _, drift := range stale {
+		refreshed[drift.Migration] = drift.Current
+	}
+
+	return refreshed, nil
+}
+
+// planRollbacks follows the logic of HandleEmbeddedRollbacks and ApplyRollbacks, returning the
+// embedded rollbacks that would be applied after the migration files.  The refreshed rollbacks
+// replace those stored in the database.
+func (m *Migrator) planRollbacks(ctx context.Context, tx *sql.Tx, version int64, applied map[string]bool, refreshed map[string]string) ([]Step, error) {
+	if version == Latest {
+		version = m.LatestRevision()
+	}
+
+	var migrations []string
+	for migration, ok := range applied {
+		if ok {
+			migrations = append(migrations, migration)
+		}
+	}
+
+	sort.Sort(SortDown(migrations))
+
+	var steps []Step
+	for _, migration := range migrations {
+		revision, err := Revision(migration)
+		if err != nil {
+			return nil, err
+		}
+
+		if revision <= version {
+			break
+		}
+
+		var downSQL string
+		row := tx.QueryRowContext(ctx, "select down from "+m.rollbacksTable()+" where migration = $1", migration)
+		if err := row.Scan(&downSQL); errors.Is(err, sql.ErrNoRows) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if down, ok := refreshed[migration]; ok {
+			downSQL = down
+		}
+
+		step := Step{
+			Migration: migration,
+			Direction: Down,
+			Revision:  revision,
+			SQL:       SQL(downSQL),
+			Source:    FromRollback,
+		}
+
+		if downSQL == "/stop" {
+			step.Modifiers = Modifiers{"/stop"}
+			step.SQL = ""
+			step.Stop = true
+			return append(steps, step), nil
+		}
+
+		if strings.HasPrefix(downSQL, notxRollback) {
+			step.SQL = SQL(strings.TrimPrefix(downSQL, notxRollback))
+			step.Modifiers = Modifiers{"/notx"}
+		}
+
+		if downSQL == funcRollback {
+			step.SQL = ""
+			step.Source = FromFunc
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
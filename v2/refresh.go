@@ -0,0 +1,272 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrRollbackDrift returned if the "down" SQL stored in migrations.rollbacks for an applied
+// migration no longer matches the Down section of the migration file.  Use errors.As with a
+// *RollbackDriftError to get the details.
+var ErrRollbackDrift = errors.New("stored rollback out of sync")
+
+// RollbackDriftError describes an applied migration whose stored "down" SQL no longer matches the
+// Down section of the migration file, e.g. because a buggy rollback was fixed after the migration
+// shipped.
+type RollbackDriftError struct {
+	Migration string // The migration filename
+	Stored    string // The "down" SQL stored in migrations.rollbacks
+	Current   string // The "down" SQL from the migration file
+}
+
+// Error describes the drift.
+func (e *RollbackDriftError) Error() string {
+	return fmt.Sprintf("%s: the Down section of %s was modified after it was applied", ErrRollbackDrift, e.Migration)
+}
+
+// Is matches ErrRollbackDrift.
+func (e *RollbackDriftError) Is(target error) bool {
+	return target == ErrRollbackDrift
+}
+
+// Diff returns a line-by-line comparison of the stored and current "down" SQL.  Lines only in the
+// stored rollback are prefixed with "- ", lines only in the migration file with "+ ", and lines
+// in both with two spaces.
+func (e *RollbackDriftError) Diff() string {
+	stored := strings.Split(e.Stored, "\n")
+	current := strings.Split(e.Current, "\n")
+
+	// Longest common subsequence of lines, from the end of each
+	lcs := make([][]int, len(stored)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(current)+1)
+	}
+
+	for i := len(stored) - 1; i >= 0; i-- {
+		for j := len(current) - 1; j >= 0; j-- {
+			if stored[i] == current[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff strings.Builder
+	i, j := 0, 0
+	for i < len(stored) || j < len(current) {
+		switch {
+		case i < len(stored) && j < len(current) && stored[i] == current[j]:
+			diff.WriteString("  " + stored[i] + "\n")
+			i++
+			j++
+		case i < len(stored) && (j == len(current) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff.WriteString("- " + stored[i] + "\n")
+			i++
+		default:
+			diff.WriteString("+ " + current[j] + "\n")
+			j++
+		}
+	}
+
+	return diff.String()
+}
+
+// StaleRollbacks compares the "down" SQL stored in migrations.rollbacks against the Down section
+// of each applied migration file, and returns the details of every stored rollback that's out of
+// sync.  Migrations without a file in the directory, or without a stored rollback, are skipped.
+func StaleRollbacks(db *sql.DB, options Options) ([]*RollbackDriftError, error) {
+	return StaleRollbacksContext(context.Background(), db, options)
+}
+
+// StaleRollbacksContext compares the stored rollbacks against the migration files, as with
+// StaleRollbacks.
+func StaleRollbacksContext(ctx context.Context, db *sql.DB, options Options) ([]*RollbackDriftError, error) {
+	return options.migrator().StaleRollbacksContext(ctx, db)
+}
+
+// RefreshStaleRollbacks replaces the "down" SQL stored in migrations.rollbacks with the Down
+// section of the migration file, for every applied migration whose stored rollback is out of
+// sync.  Returns the rollbacks that were refreshed.
+func RefreshStaleRollbacks(db *sql.DB, options Options) ([]*RollbackDriftError, error) {
+	return RefreshStaleRollbacksContext(context.Background(), db, options)
+}
+
+// RefreshStaleRollbacksContext refreshes the stored rollbacks, as with RefreshStaleRollbacks.
+func RefreshStaleRollbacksContext(ctx context.Context, db *sql.DB, options Options) ([]*RollbackDriftError, error) {
+	return options.migrator().RefreshStaleRollbacksContext(ctx, db)
+}
+
+// StaleRollbacks compares the stored rollbacks against the migration files in the migrations
+// directory.  See the package-level StaleRollbacks.
+func (m *Migrator) StaleRollbacks(db Executor) ([]*RollbackDriftError, error) {
+	return m.StaleRollbacksContext(context.Background(), db)
+}
+
+// StaleRollbacksContext compares the stored rollbacks against the migration files, as with
+// StaleRollbacks.
+func (m *Migrator) StaleRollbacksContext(ctx context.Context, db Executor) ([]*RollbackDriftError, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	return m.staleRollbacks(ctx, tx)
+}
+
+// RefreshStaleRollbacks replaces the out of sync rollbacks stored in the database with the Down
+// section of the migration files.  See the package-level RefreshStaleRollbacks.
+func (m *Migrator) RefreshStaleRollbacks(db *sql.DB) ([]*RollbackDriftError, error) {
+	return m.RefreshStaleRollbacksContext(context.Background(), db)
+}
+
+// RefreshStaleRollbacksContext refreshes the stored rollbacks, as with RefreshStaleRollbacks.
+func (m *Migrator) RefreshStaleRollbacksContext(ctx context.Context, db *sql.DB) ([]*RollbackDriftError, error) {
+	var stale []*RollbackDriftError
+
+	err := m.repairTx(ctx, db, func(tx *sql.Tx) error {
+		var err error
+		if stale, err = m.staleRollbacks(ctx, tx); err != nil {
+			return err
+		}
+
+		return m.refreshRollbacks(ctx, tx, stale)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stale, nil
+}
+
+// Checks the stored rollbacks against the migration files according to the policy, refreshing
+// any that are out of sync.  Does nothing unless Options.RefreshRollbacks was set.
+func (m *Migrator) checkRollbacks(ctx context.Context, db Executor, policy Policy) error {
+	if !m.options.RefreshStale {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stale, err := m.driftedRollbacks(ctx, tx, policy)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := m.refreshRollbacks(ctx, tx, stale); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Returns the stored rollbacks that don't match the migration files, responding according to the
+// policy:  the first returned as an error if the policy is Error, or each logged with a diff if
+// it's Warn.  Doesn't change the database.
+func (m *Migrator) driftedRollbacks(ctx context.Context, tx *sql.Tx, policy Policy) ([]*RollbackDriftError, error) {
+	stale, err := m.staleRollbacks(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, drift := range stale {
+		if policy == Error {
+			return nil, drift
+		}
+
+		if policy == Warn {
+			m.log.Infof("Warning: %s\n%s", drift, drift.Diff())
+		}
+	}
+
+	return stale, nil
+}
+
+// Returns the stored rollbacks that don't match the migration files, in revision order.
+func (m *Migrator) staleRollbacks(ctx context.Context, tx *sql.Tx) ([]*RollbackDriftError, error) {
+	if m.missingMigrationsRollbacks(ctx, tx) {
+		return nil, nil
+	}
+
+	stored, err := m.storedRollbacks(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.AppliedContext(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := m.Available(Up)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make(map[string]bool, len(migrations))
+	for _, migration := range migrations {
+		available[migration] = true
+	}
+
+	sort.Sort(SortUp(applied))
+
+	var stale []*RollbackDriftError
+	for _, migration := range applied {
+		down, ok := stored[migration]
+		if !ok || !available[migration] {
+			continue
+		}
+
+		current, err := m.expectedRollback(m.path(migration))
+		if err != nil {
+			return nil, err
+		}
+
+		if down != current {
+			stale = append(stale, &RollbackDriftError{
+				Migration: migration,
+				Stored:    down,
+				Current:   current,
+			})
+		}
+	}
+
+	return stale, nil
+}
+
+// Replaces the stored rollbacks with the current "down" SQL, and records the change in the
+// migrations history.
+func (m *Migrator) refreshRollbacks(ctx context.Context, tx *sql.Tx, stale []*RollbackDriftError) error {
+	for _, drift := range stale {
+		if _, err := tx.ExecContext(ctx, "update "+m.rollbacksTable()+" set down = $2 where migration = $1",
+			drift.Migration, drift.Current); err != nil {
+			return fmt.Errorf("unable to refresh the rollback for %s: %w", drift.Migration, err)
+		}
+
+		if err := m.recordHistory(ctx, tx, HistoryRecord{
+			Migration: drift.Migration,
+			Event:     HistoryRepair,
+			Direction: Down,
+		}); err != nil {
+			return err
+		}
+
+		m.log.Infof("Refreshed the stored rollback for %s", drift.Migration)
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrDuplicateRevision returned if more than one migration file has the same revision number,
+// e.g. after merging branches.  Use errors.As with a *DuplicateRevisionError to get the details.
+var ErrDuplicateRevision = errors.New("duplicate revision")
+
+// ErrAlreadyApplied returned by Renumber if more than one of the files sharing a revision number
+// has been applied, so the revisions can't be fixed by renumbering.
+var ErrAlreadyApplied = errors.New("colliding migrations already applied")
+
+// DuplicateRevisionError lists the migration files sharing a revision number.
+type DuplicateRevisionError struct {
+	Duplicates map[int64][]string // The colliding migration filenames, by revision
+}
+
+// Error names all the colliding files.
+func (e *DuplicateRevisionError) Error() string {
+	revisions := sortedRevisions(e.Duplicates)
+
+	var collisions []string
+	for _, revision := range revisions {
+		collisions = append(collisions, strings.Join(e.Duplicates[revision], ", "))
+	}
+
+	return fmt.Sprintf("%s: %s", ErrDuplicateRevision, strings.Join(collisions, "; "))
+}
+
+// Is matches ErrDuplicateRevision.
+func (e *DuplicateRevisionError) Is(target error) bool {
+	return target == ErrDuplicateRevision
+}
+
+// Renamed describes a migration file renamed by Renumber.
+type Renamed struct {
+	From string // The original migration filename
+	To   string // The renumbered migration filename
+}
+
+// Returns a *DuplicateRevisionError if more than one migration file has the same revision.
+func (m *Migrator) checkDuplicates() error {
+	duplicates, err := m.duplicates()
+	if err != nil {
+		return err
+	}
+
+	if len(duplicates) > 0 {
+		return &DuplicateRevisionError{Duplicates: duplicates}
+	}
+
+	return nil
+}
+
+// Returns the migration files sharing a revision number, by revision, in name order.
+func (m *Migrator) duplicates() (map[int64][]string, error) {
+	migrations, err := m.Available(Up)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make(map[int64][]string)
+	for _, migration := range migrations {
+		revision, err := Revision(migration)
+		if err != nil {
+			continue
+		}
+
+		revisions[revision] = append(revisions[revision], migration)
+	}
+
+	duplicates := make(map[int64][]string)
+	for revision, files := range revisions {
+		if len(files) > 1 {
+			sort.Strings(files)
+			duplicates[revision] = files
+		}
+	}
+
+	return duplicates, nil
+}
+
+// Renumber fixes duplicate revision numbers in the migrations directory, after merging branches,
+// by giving the newer colliding files revision numbers after the latest migration.  See
+// Options.Renumber.
+func Renumber(db *sql.DB, directory string) ([]Renamed, error) {
+	return WithDirectory(directory).Renumber(db)
+}
+
+// Renumber fixes duplicate revision numbers in the options directory, after merging branches.
+//
+// For each revision shared by more than one file, the file that has been applied to the database
+// keeps its revision.  If none have been applied, the first file by name keeps it, since file
+// modification times don't survive a checkout or merge; check the renamed files apply in the
+// right order.  The others are renamed, in order, to revisions after the latest migration, so
+// they're applied next.
+// Returns ErrAlreadyApplied without renaming anything if more than one colliding file has been
+// applied, or ErrReadOnly if the migrations can't be renamed, including registered Go migrations.
+func (options Options) Renumber(db *sql.DB) ([]Renamed, error) {
+	return options.migrator().RenumberContext(context.Background(), db)
+}
+
+// Renumber fixes duplicate revision numbers in the migrations directory.  See Options.Renumber.
+func (m *Migrator) Renumber(db Executor) ([]Renamed, error) {
+	return m.RenumberContext(context.Background(), db)
+}
+
+// RenumberContext fixes duplicate revision numbers in the migrations directory, as with Renumber.
+func (m *Migrator) RenumberContext(ctx context.Context, db Executor) ([]Renamed, error) {
+	w, ok := m.reader.(Writer)
+	if !ok {
+		return nil, ErrReadOnly
+	}
+
+	duplicates, err := m.duplicates()
+	if err != nil || len(duplicates) == 0 {
+		return nil, err
+	}
+
+	applied, err := m.appliedSet(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := sortedRevisions(duplicates)
+
+	var newer []string
+	for _, revision := range revisions {
+		files := duplicates[revision]
+
+		keep := files[0]
+		for _, file := range files {
+			if !applied[file] {
+				continue
+			}
+
+			if applied[keep] && keep != file {
+				return nil, fmt.Errorf("%w: %s", ErrAlreadyApplied, strings.Join(files, ", "))
+			}
+
+			keep = file
+		}
+
+		for _, file := range files {
+			if file == keep {
+				continue
+			}
+
+			// Go migrations are renumbered in code, where they're registered
+			if _, ok := m.funcs.lookup(file); ok {
+				return nil, fmt.Errorf("%w: %s", ErrReadOnly, file)
+			}
+
+			newer = append(newer, file)
+		}
+	}
+
+	next := m.LatestRevision()
+
+	var renamed []Renamed
+	for _, migration := range newer {
+		next++
+
+		segments := strings.SplitN(migration, "-", 2)
+		name := fmt.Sprintf("%d-%s", next, segments[1])
+
+		m.log.Infof("Renumbering migration %s to %s", migration, name)
+		if err := w.Rename(m.path(migration), m.path(name)); err != nil {
+			return renamed, err
+		}
+
+		renamed = append(renamed, Renamed{From: migration, To: name})
+	}
+
+	return renamed, nil
+}
+
+// Returns the set of migrations applied to the database, which is empty if the database hasn't
+// been initialized.
+func (m *Migrator) appliedSet(ctx context.Context, db Executor) (map[string]bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	applied := make(map[string]bool)
+	if m.missingMigrationsApplied(ctx, tx) {
+		return applied, nil
+	}
+
+	migrations, err := m.AppliedContext(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range migrations {
+		applied[migration] = true
+	}
+
+	return applied, nil
+}
+
+// Returns the revisions of the duplicates in order.
+func sortedRevisions(duplicates map[int64][]string) []int64 {
+	var revisions []int64
+	for revision := range duplicates {
+		revisions = append(revisions, revision)
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i] < revisions[j]
+	})
+
+	return revisions
+}
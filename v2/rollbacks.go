@@ -1,22 +1,32 @@
 package migrations
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"path"
+	"fmt"
 	"sort"
 	"strings"
+	"time"
 )
 
 // ErrStopped returned if the migration couldn't rollback due to a /stop modifier
 var ErrStopped = errors.New("stopped rollback due to /stop modifier")
 
+// ErrNoRollback returned if there's no "down" migration stored in the database for an applied
+// migration.
+var ErrNoRollback = errors.New("no rollback stored for the migration")
+
 // CreateMigrationsRollbacks creates the migrations.rollbacks table in the database if it doesn't already
 // exist.
 func CreateMigrationsRollbacks(tx *sql.Tx) error {
-	if MissingMigrationsRollbacks(tx) {
-		Log.Infof("Creating migrations.rollbacks table in the database")
-		if _, err := tx.Exec("create table migrations.rollbacks(migration varchar(1024) not null primary key, down text)"); err != nil {
+	return std().createMigrationsRollbacks(context.Background(), tx)
+}
+
+func (m *Migrator) createMigrationsRollbacks(ctx context.Context, tx *sql.Tx) error {
+	if m.missingMigrationsRollbacks(ctx, tx) {
+		m.log.Infof("Creating %s.%s table in the database", m.schemaName(), m.rollbacksName())
+		if _, err := tx.ExecContext(ctx, "create table "+m.rollbacksTable()+"(migration varchar(1024) not null primary key, down text)"); err != nil {
 			return err
 		}
 	}
@@ -26,25 +36,22 @@ func CreateMigrationsRollbacks(tx *sql.Tx) error {
 
 // MissingMigrationsRollbacks returns true if there is no migrations.rollbacks table in the database.
 func MissingMigrationsRollbacks(tx *sql.Tx) bool {
-	row := tx.QueryRow("select not(exists(select 1 from pg_catalog.pg_class c " +
-		"join pg_catalog.pg_namespace n " +
-		"on n.oid = c.relnamespace " +
-		"where n.nspname = 'migrations' and c.relname = 'rollbacks'))")
-
-	var result bool
-	if err := row.Scan(&result); err != nil {
-		return true
-	}
+	return std().missingMigrationsRollbacks(context.Background(), tx)
+}
 
-	return result
+func (m *Migrator) missingMigrationsRollbacks(ctx context.Context, tx *sql.Tx) bool {
+	return m.missingTable(ctx, tx, m.rollbacksName())
 }
 
 // UpdateRollback adds the migration's "down" SQL to the rollbacks table.
 func UpdateRollback(tx *sql.Tx, path string) error {
-	var err error
+	return std().updateRollback(context.Background(), tx, path)
+}
+
+func (m *Migrator) updateRollback(ctx context.Context, tx *sql.Tx, path string) error {
 	filename := Filename(path)
 
-	row := tx.QueryRow("select exists(select 1 from migrations.rollbacks where migration = $1)", filename)
+	row := tx.QueryRowContext(ctx, "select exists(select 1 from "+m.rollbacksTable()+" where migration = $1)", filename)
 	var exists bool
 	if err := row.Scan(&exists); err != nil {
 		return err
@@ -54,38 +61,66 @@ func UpdateRollback(tx *sql.Tx, path string) error {
 		return nil
 	}
 
-	downSQL, mods, err := ReadSQL(path, Down)
+	downSQL, err := m.expectedRollback(path)
 	if err != nil {
 		return err
 	}
 
-	downSQL = SQL(strings.TrimSpace(string(downSQL)))
+	if downSQL == "/stop" {
+		m.log.Infof("Storing /stop down migration for %s", path)
+	} else {
+		m.log.Infof("Storing down migration for %s, %s", path, downSQL)
+	}
+
+	_, err = tx.ExecContext(ctx, "insert into "+m.rollbacksTable()+" (migration, down) values ($1, $2)", filename, downSQL)
+	return err
+}
+
+// Returns the "down" SQL to store in migrations.rollbacks for the migration, as read from the
+// current migration file.
+func (m *Migrator) expectedRollback(path string) (string, error) {
+	// Go migrations run their registered down function instead
+	if _, ok := m.funcs.lookup(path); ok {
+		return funcRollback, nil
+	}
+
+	downSQL, mods, err := m.ReadSQL(path, Down)
+	if err != nil {
+		return "", err
+	}
 
 	// Record that the rollback should stop here, as indicated by the annotation on the Down
 	// indicator in the SQL
 	if mods.Has("/stop") {
-		Log.Infof("Storing /stop down migration for %s", path)
-		_, err = tx.Exec("insert into migrations.rollbacks (migration, down) values ($1, '/stop')", filename)
-		return err
+		return "/stop", nil
 	}
 
-	Log.Infof("Storing down migration for %s, %s", path, downSQL)
-	_, err = tx.Exec("insert into migrations.rollbacks (migration, down) values ($1, $2)", filename, downSQL)
-	return err
+	expected := strings.TrimSpace(string(downSQL))
+
+	// Record that the rollback must run outside a transaction
+	if mods.Has("/notx") {
+		expected = notxRollback + expected
+	}
+
+	return expected, nil
 }
 
 // UpdateRollbacks copies all the "down" parts of the migrations into the migrations.rollbacks table for
 // any migrations missing from that table.  Helps migrate older applications to use the newer
 // in-database rollback functionality.
 func UpdateRollbacks(tx *sql.Tx, directory string) error {
-	migrations, err := Available(directory, Up)
+	return WithDirectory(directory).migrator().updateRollbacks(context.Background(), tx)
+}
+
+func (m *Migrator) updateRollbacks(ctx context.Context, tx *sql.Tx) error {
+	migrations, err := m.Available(Up)
 	if err != nil {
 		return err
 	}
 
 	for _, migration := range migrations {
-		if err := UpdateRollback(tx, path.Join(directory, migration)); err != nil {
-			Log.Infof("Unable to record rollback in the database: %s", err)
+		if err := m.updateRollback(ctx, tx, m.path(migration)); err != nil {
+			m.log.Infof("Unable to record rollback in the database: %s", err)
 
 			_ = tx.Rollback()
 			return err
@@ -97,8 +132,29 @@ func UpdateRollbacks(tx *sql.Tx, directory string) error {
 
 // ApplyRollbacks collects any migrations stored in the database that are higher than the desired
 // revision and runs the "down" migration to roll them back.
-func ApplyRollbacks(db *sql.DB, revision int) error {
-	migrations, err := Applied(db)
+func ApplyRollbacks(db *sql.DB, revision int64) error {
+	return ApplyRollbacksContext(context.Background(), db, revision)
+}
+
+// ApplyRollbacksContext applies the rollbacks stored in the database, as with ApplyRollbacks, but
+// stops if the context is cancelled.  The rollback running at the time is rolled back, and the
+// error returned wraps ctx.Err() with the name of the migration.
+func ApplyRollbacksContext(ctx context.Context, db *sql.DB, revision int64) error {
+	return std().ApplyRollbacksContext(ctx, db, revision)
+}
+
+// ApplyRollbacks collects any migrations stored in the database that are higher than the desired
+// revision and runs the "down" migration to roll them back.  Go migrations can't store their
+// "down" step in the database, so run the down function registered with the migrator instead, or
+// return ErrNotRegistered if the Go migration is no longer registered.
+func (m *Migrator) ApplyRollbacks(db Executor, revision int64) error {
+	return m.ApplyRollbacksContext(context.Background(), db, revision)
+}
+
+// ApplyRollbacksContext applies the rollbacks stored in the database, as with ApplyRollbacks, but
+// stops if the context is cancelled.
+func (m *Migrator) ApplyRollbacksContext(ctx context.Context, db Executor, revision int64) error {
+	migrations, err := m.AppliedContext(ctx, db)
 	if err != nil {
 		return err
 	}
@@ -107,67 +163,96 @@ func ApplyRollbacks(db *sql.DB, revision int) error {
 	sort.Sort(SortDown(migrations))
 
 	for _, migration := range migrations {
-		tx, err := db.Begin()
-		if err != nil {
-			return err
-		}
-
 		migrationRevision, err := Revision(migration)
 		if err != nil {
-			_ = tx.Rollback()
 			return err
 		}
 
 		// Stop when we reach the desired revision
 		if migrationRevision <= revision {
-			_ = tx.Rollback()
 			break
 		}
 
-		var downSQL string
-		row := tx.QueryRow("select down from migrations.rollbacks where migration = $1", migration)
-		if err := row.Scan(&downSQL); errors.Is(err, sql.ErrNoRows) {
+		if err := m.rollbackStored(ctx, db, migration); errors.Is(err, ErrNoRollback) {
 			continue
 		} else if err != nil {
-			_ = tx.Rollback()
-			return err
+			return m.failed(db, m.path(migration), Down, err)
 		}
+	}
 
-		if downSQL == "/stop" {
-			_ = tx.Rollback()
-			Log.Infof("Stopping rollback per migration %s", migration)
-			return ErrStopped
-
-		} else if downSQL != "" {
-			Log.Infof("Rolling back migration %s", migration)
-
-			_, err = tx.Exec(downSQL)
-			if err != nil {
-				_ = tx.Rollback()
-				return err
-			}
-		} else {
-			Log.Infof("Skipped rolling back migration %s; no down SQL found", migration)
-		}
+	return nil
+}
+
+// Runs the "down" migration stored in the database for the migration, and removes it from the
+// applied migrations.  Returns ErrNoRollback if there's no rollback stored for the migration.
+func (m *Migrator) rollbackStored(ctx context.Context, db Executor, migration string) error {
+	if err := ctx.Err(); err != nil {
+		return interrupted(ctx, migration, Down, err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return interrupted(ctx, migration, Down, err)
+	}
 
-		// Clean out the migration now that it's been rolled back
-		if _, err := tx.Exec("delete from migrations.rollbacks where migration = $1", migration); err != nil {
-			Log.Infof("Unable to delete rollback %s: %s", migration, err)
+	start := time.Now()
+
+	var downSQL string
+	row := tx.QueryRowContext(ctx, "select down from "+m.rollbacksTable()+" where migration = $1", migration)
+	if err := row.Scan(&downSQL); errors.Is(err, sql.ErrNoRows) {
+		_ = tx.Rollback()
+		return fmt.Errorf("%s: %w", migration, ErrNoRollback)
+	} else if err != nil {
+		_ = tx.Rollback()
+		return interrupted(ctx, migration, Down, err)
+	}
+
+	if downSQL == "/stop" {
+		_ = tx.Rollback()
+		m.log.Infof("Stopping rollback per migration %s", migration)
+		return ErrStopped
+
+	} else if downSQL == funcRollback {
+		fn, ok := m.funcs.lookup(migration)
+		if !ok {
 			_ = tx.Rollback()
-			return err
+			return fmt.Errorf("unable to roll back %s: %w", migration, ErrNotRegistered)
 		}
 
-		if _, err := tx.Exec("delete from migrations.applied where migration = $1", migration); err != nil {
-			Log.Infof("Unable to delete migration %s: %s", migration, err)
+		m.log.Infof("Rolling back migration %s", migration)
+
+		if err := fn.run(ctx, tx, Down); err != nil {
 			_ = tx.Rollback()
-			return err
+			return interrupted(ctx, migration, Down, err)
 		}
+	} else if strings.HasPrefix(downSQL, notxRollback) {
+		_ = tx.Rollback()
+
+		doc := SQL(strings.TrimPrefix(downSQL, notxRollback))
+		return m.applyNoTx(ctx, db, migration, Down, doc)
+	} else if downSQL != "" {
+		m.log.Infof("Rolling back migration %s", migration)
 
-		if err := tx.Commit(); err != nil {
-			Log.Infof("Unable to rollback migration %s: %s", migration, err)
+		_, err = tx.ExecContext(ctx, downSQL)
+		if err != nil {
 			_ = tx.Rollback()
-			return err
+			return interrupted(ctx, migration, Down, err)
 		}
+	} else {
+		m.log.Infof("Skipped rolling back migration %s; no down SQL found", migration)
+	}
+
+	// Clean out the migration now that it's been rolled back
+	if err := m.track(ctx, tx, HistoryRollback, migration, Down, time.Since(start)); err != nil {
+		m.log.Infof("Unable to delete migration %s: %s", migration, err)
+		_ = tx.Rollback()
+		return interrupted(ctx, migration, Down, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		m.log.Infof("Unable to rollback migration %s: %s", migration, err)
+		_ = tx.Rollback()
+		return interrupted(ctx, migration, Down, err)
 	}
 
 	return nil
@@ -175,13 +260,23 @@ func ApplyRollbacks(db *sql.DB, revision int) error {
 
 // HandleEmbeddedRollbacks updates the rollbacks and then applies any missing and necessary
 // rollbacks to get the database to the implied versions.
-func HandleEmbeddedRollbacks(db *sql.DB, directory string, version int) error {
+func HandleEmbeddedRollbacks(db *sql.DB, directory string, version int64) error {
+	return HandleEmbeddedRollbacksContext(context.Background(), db, directory, version)
+}
+
+// HandleEmbeddedRollbacksContext applies any necessary rollbacks stored in the database, as with
+// HandleEmbeddedRollbacks, but stops if the context is cancelled.
+func HandleEmbeddedRollbacksContext(ctx context.Context, db *sql.DB, directory string, version int64) error {
+	return WithDirectory(directory).migrator().handleEmbeddedRollbacks(ctx, db, version)
+}
+
+func (m *Migrator) handleEmbeddedRollbacks(ctx context.Context, db Executor, version int64) error {
 	if version == Latest {
-		version = LatestRevision(directory)
+		version = m.LatestRevision()
 	}
 
 	// Apply the db-based rollbacks as needed
-	if err := ApplyRollbacks(db, version); err != nil {
+	if err := m.ApplyRollbacksContext(ctx, db, version); err != nil {
 		return err
 	}
 
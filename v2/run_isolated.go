@@ -2,6 +2,7 @@ package migrations
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"errors"
 	"strings"
@@ -25,7 +26,8 @@ type AsyncRequest struct {
 	Migration string    // Full path to the migration
 	Direction Direction // The direction to run
 	SQL       SQL       // The SQL to run (parsed from the migration)
-	Target    int       // The desired revision number to migration to
+	Target    int64     // The desired revision number to migration to
+	Batch     int64     // The batch ID of the Apply call that queued the migration
 }
 
 // AsyncResult is returned by asynchronous SQL migration commands on the Results channel
@@ -56,22 +58,25 @@ func HandleAsync(db *sql.DB, requests RequestChannel, results ResultChannel) {
 // RunIsolated breaks apart a SQL migration into separate commands and runs each in a single
 // transaction.  Helps asynchronous migrations return additional details about failures.
 func RunIsolated(db *sql.DB, req AsyncRequest) (SQL, error) {
+	return RunIsolatedContext(context.Background(), db, req)
+}
+
+// RunIsolatedContext runs each command in the SQL migration, as with RunIsolated, but stops if the
+// context is cancelled.
+func RunIsolatedContext(ctx context.Context, db *sql.DB, req AsyncRequest) (SQL, error) {
 	commands, err := ParseSQL(req.SQL)
 	if err != nil {
 		return "", err
 	}
 
-	tx, err := db.Begin()
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return "", err
 	}
 
-	for _, SQL := range commands {
-		_, err = tx.Exec(string(SQL))
-		if err != nil {
-			_ = tx.Rollback()
-			return SQL, err
-		}
+	if cmd, err := execCommands(ctx, tx, commands); err != nil {
+		_ = tx.Rollback()
+		return cmd, err
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -81,6 +86,28 @@ func RunIsolated(db *sql.DB, req AsyncRequest) (SQL, error) {
 	return "", nil
 }
 
+// Breaks apart the SQL migration into separate commands and runs each in the transaction.
+// Returns the command that failed, if any.
+func runIsolated(ctx context.Context, tx *sql.Tx, doc SQL) (SQL, error) {
+	commands, err := ParseSQL(doc)
+	if err != nil {
+		return "", err
+	}
+
+	return execCommands(ctx, tx, commands)
+}
+
+// Runs each command in the transaction, returning the command that failed, if any.
+func execCommands(ctx context.Context, tx *sql.Tx, commands []SQL) (SQL, error) {
+	for _, cmd := range commands {
+		if _, err := tx.ExecContext(ctx, string(cmd)); err != nil {
+			return cmd, err
+		}
+	}
+
+	return "", nil
+}
+
 // ParseSQL breaks the SQL document apart into individual commands, so we can submit them to the
 // database one at a time.
 func ParseSQL(doc SQL) ([]SQL, error) {
@@ -0,0 +1,107 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrSingleTransaction returned if the migrations are to be applied in a single transaction, but
+// a pending migration has a /notx or /async modifier, so can't run in one.
+var ErrSingleTransaction = errors.New("migrations can't run in a single transaction")
+
+// A migration to run in the single transaction.
+type pendingMigration struct {
+	migration string
+	path      string
+	SQL       SQL
+	mods      Modifiers
+}
+
+// Applies all the pending migrations in a single transaction.  Nothing is run if any of the
+// pending migrations can't run in a transaction.  If a rollback reaches a /stop modifier, the
+// migrations rolled back before it are committed, as they would be one at a time.
+func (m *Migrator) applySingleTx(ctx context.Context, db Executor, direction Direction, migrations []string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var pending []pendingMigration
+	var rejected []string
+
+	for _, migration := range migrations {
+		path := m.path(migration)
+
+		if !m.shouldRun(ctx, tx, path, direction, m.options.Revision) {
+			continue
+		}
+
+		SQL, mods, err := m.ReadSQL(path, direction)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		for _, mod := range []string{"/notx", "/async"} {
+			if mods.Has(mod) {
+				rejected = append(rejected, fmt.Sprintf("%s (%s)", migration, mod))
+			}
+		}
+
+		pending = append(pending, pendingMigration{migration: migration, path: path, SQL: SQL, mods: mods})
+	}
+
+	if len(rejected) > 0 {
+		_ = tx.Rollback()
+		return fmt.Errorf("%w: %s", ErrSingleTransaction, strings.Join(rejected, ", "))
+	}
+
+	if len(pending) > 0 {
+		m.log.Infof("Applying %d migrations in a single transaction", len(pending))
+	}
+
+	for _, p := range pending {
+		if err := ctx.Err(); err != nil {
+			_ = tx.Rollback()
+			return interrupted(ctx, p.path, direction, err)
+		}
+
+		if direction == Down && p.mods.Has("/stop") {
+			m.log.Infof("Interrupting migrations due to /stop indicator in %s %s", p.path, direction)
+			if err := tx.Commit(); err != nil {
+				return err
+			}
+
+			return ErrStopped
+		}
+
+		if err := m.beforeEach(ctx, tx, p.path, direction); err != nil {
+			_ = tx.Rollback()
+			return m.failed(db, p.path, direction, interrupted(ctx, p.path, direction, err))
+		}
+
+		m.log.Infof("Applying migration %s %s", p.path, direction)
+
+		start := time.Now()
+
+		if err := m.exec(ctx, tx, p.migration, direction, p.SQL); err != nil {
+			_ = tx.Rollback()
+			return m.failed(db, p.path, direction, interrupted(ctx, p.path, direction, err))
+		}
+
+		if err := m.migrated(ctx, tx, p.path, direction, time.Since(start)); err != nil {
+			_ = tx.Rollback()
+			return m.failed(db, p.path, direction, interrupted(ctx, p.path, direction, err))
+		}
+
+		if err := m.afterEach(ctx, tx, p.path, direction); err != nil {
+			_ = tx.Rollback()
+			return m.failed(db, p.path, direction, interrupted(ctx, p.path, direction, err))
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,215 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// State describes where a migration stands in the database.
+type State string
+
+const (
+	// StatePending migrations have a file but haven't been applied.
+	StatePending State = "pending"
+
+	// StateApplied migrations have been applied and have a file.
+	StateApplied State = "applied"
+
+	// StateMissing migrations were applied, but have no file and no rollback stored in the
+	// database, so they can't be rolled back.
+	StateMissing State = "missing"
+
+	// StateRollbackStored migrations were applied and have no file, but their rollback is stored
+	// in the database, so the embedded rollbacks will roll them back.
+	StateRollbackStored State = "rollback-stored"
+
+	// StateStopped migrations were applied and have no file, and their stored rollback has a
+	// /stop modifier, so the embedded rollbacks will stop at them.
+	StateStopped State = "stopped"
+
+	// StateDirty migrations have a /notx modifier and failed partway, so the database must be
+	// repaired before any more migrations are applied.
+	StateDirty State = "dirty"
+)
+
+// MigrationStatus describes a migration known to the files or the database.
+type MigrationStatus struct {
+	Revision  int64     `json:"revision"`   // The revision number of the migration
+	Migration string    `json:"migration"`  // The migration filename
+	State     State     `json:"state"`      // Where the migration stands
+	AppliedAt time.Time `json:"applied_at"` // When the migration was applied, if known
+
+	// Modified is true if the migration file was changed after the migration was applied.
+	Modified bool `json:"modified,omitempty"`
+
+	// RollbackDrift is true if the rollback stored in the database doesn't match the "down"
+	// SQL in the migration file.
+	RollbackDrift bool `json:"rollback_drift,omitempty"`
+}
+
+// Status lists every revision known to the migration files or the database, and where it stands.
+func Status(db *sql.DB, options Options) ([]MigrationStatus, error) {
+	return options.Status(db)
+}
+
+// Status lists every revision known to the migration files in the options directory or the
+// database, and where it stands, in revision order.  Doesn't change the database.
+func (options Options) Status(db *sql.DB) ([]MigrationStatus, error) {
+	return options.StatusContext(context.Background(), db)
+}
+
+// StatusContext lists every revision and where it stands, as with Status.
+func (options Options) StatusContext(ctx context.Context, db *sql.DB) ([]MigrationStatus, error) {
+	return options.migrator().StatusContext(ctx, db)
+}
+
+// Status lists every revision known to the migration files or the database, and where it stands.
+// See Options.Status.
+func (m *Migrator) Status(db Executor) ([]MigrationStatus, error) {
+	return m.StatusContext(context.Background(), db)
+}
+
+// StatusContext lists every revision and where it stands, as with Status.
+func (m *Migrator) StatusContext(ctx context.Context, db Executor) ([]MigrationStatus, error) {
+	files, err := m.Available(Up)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read-only, so the report doesn't lock or change the tracking tables
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	applied := make(map[string]AppliedRecord)
+	rollbacks := make(map[string]string)
+
+	if !m.missingMigrationsApplied(ctx, tx) {
+		// Columns missing from an earlier release are read as blank, rather than added
+		present, err := m.appliedColumnNames(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		records, err := m.appliedMigrations(ctx, tx, present)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range records {
+			applied[record.Migration] = record
+		}
+	}
+
+	if !m.missingMigrationsRollbacks(ctx, tx) {
+		rollbacks, err = m.storedRollbacks(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var results []MigrationStatus
+	for _, migration := range files {
+		revision, _ := Revision(migration)
+		status := MigrationStatus{
+			Revision:  revision,
+			Migration: migration,
+			State:     StatePending,
+		}
+
+		if record, ok := applied[migration]; ok {
+			delete(applied, migration)
+
+			status.State = StateApplied
+			status.AppliedAt = record.AppliedAt
+
+			if record.Dirty {
+				status.State = StateDirty
+			}
+
+			if record.Checksum != "" {
+				sum, err := m.Checksum(m.path(migration))
+				if err != nil {
+					return nil, err
+				}
+
+				status.Modified = sum != record.Checksum
+			}
+
+			if stored, ok := rollbacks[migration]; ok {
+				drift, err := m.rollbackDrift(migration, stored)
+				if err != nil {
+					return nil, err
+				}
+
+				status.RollbackDrift = drift
+			}
+		}
+
+		results = append(results, status)
+	}
+
+	// Whatever's left was applied, but the file is gone
+	for migration, record := range applied {
+		status := MigrationStatus{
+			Revision:  record.Revision,
+			Migration: migration,
+			State:     StateMissing,
+			AppliedAt: record.AppliedAt,
+		}
+
+		if stored, ok := rollbacks[migration]; ok {
+			status.State = StateRollbackStored
+			if stored == "/stop" {
+				status.State = StateStopped
+			}
+		}
+
+		results = append(results, status)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Revision < results[j].Revision
+	})
+
+	return results, nil
+}
+
+// Returns true if the rollback stored in the database doesn't match the migration file.
+func (m *Migrator) rollbackDrift(migration string, stored string) (bool, error) {
+	expected, err := m.expectedRollback(m.path(migration))
+	if err != nil {
+		return false, err
+	}
+
+	return stored != expected, nil
+}
+
+// Returns the rollbacks stored in the database, mapped by migration filename.
+func (m *Migrator) storedRollbacks(ctx context.Context, conn QueryableContext) (map[string]string, error) {
+	rows, err := conn.QueryContext(ctx, "select migration, coalesce(down, '') from "+m.rollbacksTable())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	results := make(map[string]string)
+	for rows.Next() {
+		var migration, down string
+		if err := rows.Scan(&migration, &down); err != nil {
+			return nil, err
+		}
+
+		results[migration] = down
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Renders the migration SQL as a template with the environment variables and the options Vars.
+// Referencing an unknown variable is an error.
+func (m *Migrator) render(path string, doc SQL) (SQL, error) {
+	tmpl, err := template.New(Filename(path)).Option("missingkey=error").Parse(string(doc))
+	if err != nil {
+		return "", fmt.Errorf("invalid template in %s: %w", Filename(path), err)
+	}
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, m.vars()); err != nil {
+		return "", fmt.Errorf("unable to render %s: %w", Filename(path), err)
+	}
+
+	return SQL(rendered.String()), nil
+}
+
+// Returns the template variables:  the environment variables, overridden by the options Vars.
+func (m *Migrator) vars() map[string]string {
+	vars := make(map[string]string)
+	for _, env := range os.Environ() {
+		if name, value, ok := strings.Cut(env, "="); ok {
+			vars[name] = value
+		}
+	}
+
+	for name, value := range m.options.Vars {
+		vars[name] = value
+	}
+
+	return vars
+}
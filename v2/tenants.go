@@ -0,0 +1,276 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// TenantAppliedTable is the table in each tenant schema recording the applied migrations,
+	// unless the options name a different table.
+	TenantAppliedTable = "migrations_applied"
+
+	// TenantRollbacksTable is the table in each tenant schema storing the rollbacks, unless the
+	// options name a different table.
+	TenantRollbacksTable = "migrations_rollbacks"
+)
+
+// ErrTenantsFailed returned by ApplyTenants if any of the tenant schemas couldn't be migrated.
+// Check the TenantResult for each tenant for the details.
+var ErrTenantsFailed = errors.New("unable to migrate all the tenants")
+
+// Tenants identifies the tenant schemas to migrate, in a schema-per-tenant database.
+type Tenants struct {
+	// Schemas lists the tenant schemas to migrate.
+	Schemas []string
+
+	// Query discovers the tenant schemas to migrate, if Schemas is empty.  It must return a
+	// single column with the schema names, e.g. "select name from tenants".
+	Query string
+
+	// Shared schemas, such as "public", are added to the end of each tenant's search_path so
+	// the migrations may reference shared tables, types, and extensions.
+	Shared []string
+
+	// Parallelism is how many tenants to migrate at the same time.  Defaults to 1.
+	Parallelism int
+
+	// FailFast stops migrating the tenants after the first failure.  Tenants being migrated at
+	// the time are interrupted, and the remainder are skipped.  By default, the remaining
+	// tenants are migrated regardless.
+	FailFast bool
+}
+
+// TenantResult reports the outcome of migrating a tenant schema.
+type TenantResult struct {
+	Schema   string        // The tenant schema
+	Err      error         // Why the tenant couldn't be migrated; nil if it succeeded
+	Skipped  bool          // The tenant wasn't migrated, due to FailFast or cancellation
+	Duration time.Duration // How long it took to migrate the tenant
+}
+
+// ApplyTenants applies the migrations to each tenant schema using the default options.  See
+// Options.ApplyTenants.
+func ApplyTenants(db *sql.DB, tenants Tenants) ([]TenantResult, error) {
+	return DefaultOptions().ApplyTenants(db, tenants)
+}
+
+// ApplyTenants applies the migrations to each tenant schema, returning a result for every tenant.
+//
+// Each tenant is migrated on its own connection, with the search_path set to the tenant schema,
+// so unqualified tables in the migrations are created in the tenant schema.  The migrations
+// applied to each tenant are tracked in the tenant schema itself, in the TenantAppliedTable and
+// TenantRollbacksTable tables by default.  If locking is enabled, each tenant has its own
+// advisory lock, derived from the lock key and the schema name.
+//
+// Returns an error wrapping ErrTenantsFailed if any of the tenants failed or were skipped.
+func (options Options) ApplyTenants(db *sql.DB, tenants Tenants) ([]TenantResult, error) {
+	return options.ApplyTenantsContext(context.Background(), db, tenants)
+}
+
+// ApplyTenantsContext applies the migrations to each tenant schema, as with ApplyTenants, but
+// stops if the context is cancelled.
+func (options Options) ApplyTenantsContext(ctx context.Context, db *sql.DB, tenants Tenants) ([]TenantResult, error) {
+	return options.migrator().ApplyTenantsContext(ctx, db, tenants)
+}
+
+// ApplyTenants applies the migrations to each tenant schema.  See Options.ApplyTenants.
+func (m *Migrator) ApplyTenants(db *sql.DB, tenants Tenants) ([]TenantResult, error) {
+	return m.ApplyTenantsContext(context.Background(), db, tenants)
+}
+
+// ApplyTenantsContext applies the migrations to each tenant schema, as with ApplyTenants, but
+// stops if the context is cancelled.
+func (m *Migrator) ApplyTenantsContext(ctx context.Context, db *sql.DB, tenants Tenants) ([]TenantResult, error) {
+	schemas, err := tenants.discover(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	parallelism := tenants.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]TenantResult, len(schemas))
+	sem := make(chan struct{}, parallelism)
+
+	var wg sync.WaitGroup
+	for i, schema := range schemas {
+		results[i].Schema = schema
+
+		acquired := false
+		select {
+		case sem <- struct{}{}:
+			acquired = true
+		case <-ctx.Done():
+		}
+
+		// Skip the remaining tenants if a tenant failed fast or the context was cancelled
+		if ctx.Err() != nil {
+			results[i].Skipped = true
+
+			if acquired {
+				<-sem
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *TenantResult) {
+			defer wg.Done()
+			defer func() {
+				<-sem
+			}()
+
+			start := time.Now()
+			result.Err = m.applyTenant(ctx, db, result.Schema, tenants.Shared)
+			result.Duration = time.Since(start)
+
+			if result.Err != nil {
+				m.log.Infof("Unable to migrate tenant %s: %s", result.Schema, result.Err)
+
+				if tenants.FailFast {
+					cancel()
+				}
+			}
+		}(&results[i])
+	}
+
+	wg.Wait()
+
+	var failed, skipped int
+	for _, result := range results {
+		if result.Skipped {
+			skipped++
+		} else if result.Err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 || skipped > 0 {
+		return results, fmt.Errorf("%w: %d failed and %d skipped of %d", ErrTenantsFailed, failed, skipped, len(results))
+	}
+
+	return results, nil
+}
+
+// Migrates a single tenant on a dedicated connection, with the search_path set to the tenant
+// schema.  The search_path is reset before the connection is returned to the pool.
+func (m *Migrator) applyTenant(ctx context.Context, db *sql.DB, schema string, shared []string) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	path := []string{QuoteIdentifier(schema)}
+	for _, name := range shared {
+		path = append(path, QuoteIdentifier(name))
+	}
+
+	if _, err := conn.ExecContext(ctx, "select set_config('search_path', $1, false)", strings.Join(path, ", ")); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "reset search_path"); err != nil {
+			m.log.Infof("Unable to reset the search_path after migrating tenant %s: %s", schema, err)
+		}
+	}()
+
+	tenant := m.tenant(schema)
+
+	if tenant.options.Lock {
+		key := tenantLockKey(tenant.options.LockKey, schema)
+		if err := tenant.lockConn(ctx, conn, key, tenant.options.LockTimeout); err != nil {
+			return err
+		}
+		defer func() {
+			if _, err := conn.ExecContext(context.Background(), "select pg_advisory_unlock($1)", key); err != nil {
+				tenant.log.Infof("Unable to release the migrations lock: %s", err)
+			}
+		}()
+	}
+
+	return tenant.apply(ctx, conn)
+}
+
+// Returns a migrator tracking the migrations in the tenant schema and logging the tenant name.
+func (m *Migrator) tenant(schema string) *Migrator {
+	options := m.options.WithTrackingSchema(schema)
+
+	if m.appliedName() == DefaultAppliedTable {
+		options.AppliedTable = TenantAppliedTable
+	}
+
+	if m.rollbacksName() == DefaultRollbacksTable {
+		options.RollbacksTable = TenantRollbacksTable
+	}
+
+	tenant := m.with(options)
+	tenant.log = &tenantLogger{schema: schema, log: m.log}
+
+	return tenant
+}
+
+// Returns the tenant schemas listed or discovered by the query.
+func (tenants Tenants) discover(ctx context.Context, db *sql.DB) ([]string, error) {
+	if len(tenants.Schemas) > 0 || tenants.Query == "" {
+		return tenants.Schemas, nil
+	}
+
+	rows, err := db.QueryContext(ctx, tenants.Query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var schemas []string
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return nil, err
+		}
+
+		schemas = append(schemas, schema)
+	}
+
+	return schemas, rows.Err()
+}
+
+// Returns the advisory lock key for the tenant, so tenants may be migrated in parallel.
+func tenantLockKey(key int64, schema string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(schema))
+
+	return key ^ int64(h.Sum64())
+}
+
+// tenantLogger prefixes log messages with the tenant schema.
+type tenantLogger struct {
+	schema string
+	log    Logger
+}
+
+// Debugf logs a debug message for the tenant.
+func (t *tenantLogger) Debugf(format string, args ...interface{}) {
+	t.log.Debugf("[%s] "+format, append([]interface{}{t.schema}, args...)...)
+}
+
+// Infof logs an informational message for the tenant.
+func (t *tenantLogger) Infof(format string, args ...interface{}) {
+	t.log.Infof("[%s] "+format, append([]interface{}{t.schema}, args...)...)
+}
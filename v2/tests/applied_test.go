@@ -0,0 +1,92 @@
+package tests_test
+
+import (
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Are the details of each migration recorded when it's applied?
+func TestAppliedMigrations(t *testing.T) {
+	defer clean(t)
+
+	options := migrations.WithRevision(2).WithActor("deploy-42").WithAppVersion("1.4.0")
+	if err := options.Apply(conn); err != nil {
+		t.Fatalf("Unable to apply migrations: %s", err)
+	}
+
+	records, err := migrations.AppliedMigrations(conn)
+	if err != nil {
+		t.Fatalf("Unable to get the applied migrations: %s", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 applied migrations; got %d", len(records))
+	}
+
+	for i, record := range records {
+		if record.Revision != int64(i+1) {
+			t.Errorf("Expected revision %d; got %d (%s)", i+1, record.Revision, record.Migration)
+		}
+
+		if record.AppliedAt.IsZero() {
+			t.Errorf("Expected %s to record when it was applied", record.Migration)
+		}
+
+		if record.AppliedBy == "" {
+			t.Errorf("Expected %s to record the database user", record.Migration)
+		}
+
+		if record.Actor != "deploy-42" {
+			t.Errorf("Expected actor deploy-42; got %q", record.Actor)
+		}
+
+		if record.AppVersion != "1.4.0" {
+			t.Errorf("Expected app version 1.4.0; got %q", record.AppVersion)
+		}
+
+		if record.LibraryVersion != migrations.Version {
+			t.Errorf("Expected library version %s; got %q", migrations.Version, record.LibraryVersion)
+		}
+
+		if record.Checksum == "" {
+			t.Errorf("Expected %s to record its checksum", record.Migration)
+		}
+	}
+}
+
+// Are the metadata columns added to a migrations.applied table from an earlier release?
+func TestUpgradeMigrationsApplied(t *testing.T) {
+	defer clean(t)
+
+	if err := migrations.WithRevision(1).Apply(conn); err != nil {
+		t.Fatalf("Unable to apply migrations: %s", err)
+	}
+
+	// Strip the table back to the columns from an earlier release
+	if _, err := conn.Exec("alter table migrations.applied drop column applied_at, drop column duration_ms, " +
+		"drop column applied_by, drop column actor, drop column app_version, drop column library_version"); err != nil {
+		t.Fatalf("Unable to drop the metadata columns: %s", err)
+	}
+
+	if err := migrations.WithRevision(2).Apply(conn); err != nil {
+		t.Fatalf("Unable to apply migrations: %s", err)
+	}
+
+	records, err := migrations.AppliedMigrations(conn)
+	if err != nil {
+		t.Fatalf("Unable to get the applied migrations: %s", err)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 applied migrations; got %d", len(records))
+	}
+
+	if !records[0].AppliedAt.IsZero() || records[0].LibraryVersion != "" {
+		t.Errorf("Expected no details for the migration applied before the upgrade; got %+v", records[0])
+	}
+
+	if records[1].AppliedAt.IsZero() || records[1].LibraryVersion != migrations.Version {
+		t.Errorf("Expected details for the migration applied after the upgrade; got %+v", records[1])
+	}
+}
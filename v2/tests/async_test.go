@@ -0,0 +1,155 @@
+package tests_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Do /async migrations run in the background and get recorded once they succeed?
+func TestApplyAsync(t *testing.T) {
+	defer clean(t)
+
+	handle, err := migrations.WithDirectory("./sql_async").ApplyAsync(conn)
+	if err != nil {
+		t.Fatalf("Unable to apply the migrations: %s", err)
+	}
+
+	if err := handle.Wait(); err != nil {
+		t.Fatalf("Asynchronous migration failed: %s", err)
+	}
+
+	for _, migration := range []string{"1-create-accounts.sql", "2-backfill-accounts.sql", "3-create-roles.sql"} {
+		if err := migrationApplied(migration); err != nil {
+			t.Errorf("Expected %s to be applied: %s", migration, err)
+		}
+	}
+
+	statuses, err := migrations.AsyncMigrations(conn)
+	if err != nil {
+		t.Fatalf("Unable to get the asynchronous migrations: %s", err)
+	}
+
+	if len(statuses) != 1 || statuses[0].Migration != "2-backfill-accounts.sql" || statuses[0].State != migrations.AsyncSucceeded {
+		t.Errorf("Expected the backfill to succeed; got %+v", statuses)
+	}
+}
+
+// Are failed /async migrations recorded and retried?
+func TestApplyAsyncFailed(t *testing.T) {
+	defer clean(t)
+
+	handle, err := migrations.WithDirectory("./sql_async_failed").ApplyAsync(conn)
+	if err != nil {
+		t.Fatalf("Unable to apply the migrations: %s", err)
+	}
+
+	err = handle.Wait()
+	if !errors.Is(err, migrations.ErrAsyncFailed) {
+		t.Fatalf("Expected the asynchronous migration to fail; got %v", err)
+	}
+
+	var failed *migrations.AsyncError
+	if !errors.As(err, &failed) || failed.Statement != "insert into missing_table (name) values ('bob')" {
+		t.Errorf("Expected the failed statement; got %v", err)
+	}
+
+	if err := migrationApplied("2-backfill-accounts.sql"); err == nil {
+		t.Errorf("Expected the failed migration not to be applied")
+	}
+
+	if err := migrationApplied("3-create-roles.sql"); err != nil {
+		t.Errorf("Expected the synchronous migration to be applied: %s", err)
+	}
+
+	statuses, err := migrations.AsyncMigrations(conn)
+	if err != nil {
+		t.Fatalf("Unable to get the asynchronous migrations: %s", err)
+	}
+
+	if len(statuses) != 1 || statuses[0].State != migrations.AsyncFailed || statuses[0].Error == "" {
+		t.Errorf("Expected the backfill to be recorded as failed; got %+v", statuses)
+	}
+
+	// Fixed, and retried synchronously
+	if err := migrations.WithDirectory("./sql_async").Apply(conn); err != nil {
+		t.Fatalf("Unable to retry the migration: %s", err)
+	}
+
+	if err := migrationApplied("2-backfill-accounts.sql"); err != nil {
+		t.Errorf("Expected the migration to be retried: %s", err)
+	}
+}
+
+// Are /async migrations abandoned by a crashed process retried once they time out?
+func TestApplyAsyncTimeout(t *testing.T) {
+	defer clean(t)
+
+	stuckAsync(t)
+	options := migrations.WithDirectory("./sql_async")
+
+	if err := options.Apply(conn); err != nil {
+		t.Fatalf("Unable to apply the migrations: %s", err)
+	}
+
+	if err := migrationApplied("2-backfill-accounts.sql"); err == nil {
+		t.Fatalf("Expected the running migration to be skipped")
+	}
+
+	if err := options.WithAsyncTimeout(time.Minute).Apply(conn); err != nil {
+		t.Fatalf("Unable to apply the migrations: %s", err)
+	}
+
+	if err := migrationApplied("2-backfill-accounts.sql"); err != nil {
+		t.Errorf("Expected the abandoned migration to be retried: %s", err)
+	}
+}
+
+// Does ResetAsync clear an abandoned /async migration, so it's retried?
+func TestResetAsync(t *testing.T) {
+	defer clean(t)
+
+	stuckAsync(t)
+	options := migrations.WithDirectory("./sql_async")
+
+	if err := migrations.ResetAsync(conn, options, 3); !errors.Is(err, migrations.ErrUnknownRevision) {
+		t.Errorf("Expected an unknown revision error; got %v", err)
+	}
+
+	if err := migrations.ResetAsync(conn, options, 2); err != nil {
+		t.Fatalf("Unable to reset the migration: %s", err)
+	}
+
+	if err := options.Apply(conn); err != nil {
+		t.Fatalf("Unable to apply the migrations: %s", err)
+	}
+
+	if err := migrationApplied("2-backfill-accounts.sql"); err != nil {
+		t.Errorf("Expected the reset migration to be retried: %s", err)
+	}
+}
+
+// Applies the /async migrations, then leaves the backfill as though the process running it
+// crashed an hour ago.
+func stuckAsync(t *testing.T) {
+	handle, err := migrations.WithDirectory("./sql_async").ApplyAsync(conn)
+	if err != nil {
+		t.Fatalf("Unable to apply the migrations: %s", err)
+	}
+
+	if err := handle.Wait(); err != nil {
+		t.Fatalf("Asynchronous migration failed: %s", err)
+	}
+
+	for _, stmt := range []string{
+		"delete from migrations.applied where migration = '2-backfill-accounts.sql'",
+		"delete from accounts",
+		"update migrations.applied_async set state = 'running', updated_at = now() - interval '1 hour'",
+	} {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("Unable to prepare the migrations: %s", err)
+		}
+	}
+}
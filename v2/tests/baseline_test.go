@@ -0,0 +1,50 @@
+package tests_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Does Baseline record the migrations without running them?
+func TestBaseline(t *testing.T) {
+	defer clean(t)
+
+	options := migrations.WithDirectory("./sql")
+	if err := migrations.Baseline(conn, options, 2); err != nil {
+		t.Fatalf("Unable to baseline the database: %s", err)
+	}
+
+	for _, migration := range []string{"1-create-sample.sql", "2-add-email-to-sample.sql"} {
+		if err := migrationApplied(migration); err != nil {
+			t.Errorf("Expected %s to be baselined: %s", migration, err)
+		}
+	}
+
+	if err := migrationApplied("3-sample-data.sql"); err == nil {
+		t.Error("Expected 3-sample-data.sql not to be baselined")
+	}
+
+	if err := tableExists("samples"); err == nil {
+		t.Error("Expected the baselined migrations not to run")
+	}
+
+	var down string
+	row := conn.QueryRow("select down from migrations.rollbacks where migration = '1-create-sample.sql'")
+	if err := row.Scan(&down); err != nil {
+		t.Fatalf("Expected the rollback to be stored: %s", err)
+	}
+
+	if down == "" {
+		t.Error("Expected the down SQL to be stored")
+	}
+
+	if err := migrations.Baseline(conn, options, 2); !errors.Is(err, migrations.ErrNotEmpty) {
+		t.Errorf("Expected baselining again to fail; got %v", err)
+	}
+
+	if err := migrations.ForceBaseline(conn, options, 2); err != nil {
+		t.Errorf("Expected forcing the baseline to succeed: %s", err)
+	}
+}
@@ -0,0 +1,73 @@
+package tests_test
+
+import (
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Are the migrations applied by each call to Apply stamped with the same batch?
+func TestBatch(t *testing.T) {
+	defer clean(t)
+
+	if err := migrate(1); err != nil {
+		t.Fatalf("Unable to run migration to revision 1: %s", err)
+	}
+
+	if err := migrate(2); err != nil {
+		t.Fatalf("Unable to run migration to revision 2: %s", err)
+	}
+
+	applied, err := migrations.AppliedMigrations(conn)
+	if err != nil {
+		t.Fatalf("Unable to get the applied migrations: %s", err)
+	}
+
+	if len(applied) != 2 {
+		t.Fatalf("Expected 2 applied migrations; got %d", len(applied))
+	}
+
+	if applied[0].Batch == 0 || applied[1].Batch != applied[0].Batch+1 {
+		t.Errorf("Expected consecutive batches; got %d and %d", applied[0].Batch, applied[1].Batch)
+	}
+}
+
+// Does RollbackBatch roll back everything applied by the last calls to Apply?
+func TestRollbackBatch(t *testing.T) {
+	defer clean(t)
+
+	options := migrations.WithDirectory("./sql_batch")
+	if err := options.WithRevision(1).Apply(conn); err != nil {
+		t.Fatalf("Unable to apply the first batch: %s", err)
+	}
+
+	if err := options.Apply(conn); err != nil {
+		t.Fatalf("Unable to apply the second batch: %s", err)
+	}
+
+	if err := options.RollbackBatch(conn, 1); err != nil {
+		t.Fatalf("Unable to roll back the last batch: %s", err)
+	}
+
+	for _, migration := range []string{"2-add-email.sql", "3-add-phone.sql"} {
+		if err := migrationApplied(migration); err == nil {
+			t.Errorf("Expected %s to be rolled back", migration)
+		}
+	}
+
+	if err := migrationApplied("1-create-accounts.sql"); err != nil {
+		t.Errorf("Expected the first batch to remain applied: %s", err)
+	}
+
+	if _, err := conn.Exec("select email from accounts"); err == nil {
+		t.Error("Expected the email column to be rolled back")
+	}
+
+	if err := options.RollbackBatch(conn, 1); err != nil {
+		t.Fatalf("Unable to roll back the first batch: %s", err)
+	}
+
+	if err := tableExists("accounts"); err == nil {
+		t.Error("Expected the accounts table to be rolled back")
+	}
+}
@@ -0,0 +1,98 @@
+package tests_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Is the checksum calculated from the "up" SQL?
+func TestChecksum(t *testing.T) {
+	checksum, err := migrations.Checksum("./sql/match_hash.txt")
+	if err != nil {
+		t.Fatalf("Unable to calculate checksum: %s", err)
+	}
+
+	sum := sha256.Sum256([]byte("Matched Up"))
+	if expected := hex.EncodeToString(sum[:]); checksum != expected {
+		t.Errorf("Expected checksum %s, got %s", expected, checksum)
+	}
+}
+
+// Are modified migration files detected?
+func TestChecksumMismatch(t *testing.T) {
+	defer clean(t)
+
+	if err := migrate(2); err != nil {
+		t.Fatalf("Unable to run migration to revision 2: %s", err)
+	}
+
+	// Simulate editing the file after it was applied
+	if _, err := conn.Exec("update migrations.applied set checksum = 'modified' where migration = '1-create-sample.sql'"); err != nil {
+		t.Fatalf("Unable to modify the checksum: %s", err)
+	}
+
+	err := migrate(2)
+	if !errors.Is(err, migrations.ErrChecksumMismatch) {
+		t.Fatalf("Expected a checksum mismatch; got %v", err)
+	}
+
+	var mismatch *migrations.ChecksumError
+	if !errors.As(err, &mismatch) || mismatch.Migration != "1-create-sample.sql" {
+		t.Errorf("Expected the mismatch to name 1-create-sample.sql; got %v", err)
+	}
+
+	if err := migrations.WithRevision(2).WithChecksumPolicy(migrations.Warn).Apply(conn); err != nil {
+		t.Errorf("Expected a warning for the mismatch; got %s", err)
+	}
+
+	mismatches, err := migrations.Verify(conn, "./sql")
+	if err != nil {
+		t.Fatalf("Unable to verify the migrations: %s", err)
+	}
+
+	if len(mismatches) != 1 {
+		t.Errorf("Expected one mismatch; got %d", len(mismatches))
+	}
+
+	updated, err := migrations.RepairChecksums(conn, "./sql")
+	if err != nil {
+		t.Fatalf("Unable to repair the checksums: %s", err)
+	}
+
+	if updated != 1 {
+		t.Errorf("Expected to repair one checksum; repaired %d", updated)
+	}
+
+	history, err := migrations.History(conn, migrations.HistoryFilter{
+		Migration: "1-create-sample.sql",
+		Events:    []migrations.HistoryEvent{migrations.HistoryRepair},
+	})
+	if err != nil {
+		t.Fatalf("Unable to get the history: %s", err)
+	}
+
+	if len(history) != 1 {
+		t.Fatalf("Expected one repair in the history; got %d", len(history))
+	}
+
+	if history[0].Previous != "modified" {
+		t.Errorf("Expected the previous checksum to be recorded; got %q", history[0].Previous)
+	}
+
+	checksum, err := migrations.Checksum("./sql/1-create-sample.sql")
+	if err != nil {
+		t.Fatalf("Unable to calculate the checksum: %s", err)
+	}
+
+	if history[0].Checksum != checksum {
+		t.Errorf("Expected the repaired checksum %s; got %q", checksum, history[0].Checksum)
+	}
+
+	if err := migrate(2); err != nil {
+		t.Errorf("Expected migrations to succeed after repair: %s", err)
+	}
+}
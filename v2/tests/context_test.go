@@ -0,0 +1,32 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Does a context deadline interrupt a hung migration?
+func TestApplyContext(t *testing.T) {
+	defer clean(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	err := migrations.WithDirectory("./sql_slow").ApplyContext(ctx, conn)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the migration to exceed the deadline; got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "1-slow-query.sql") {
+		t.Errorf("Expected the error to name the interrupted migration; got %s", err)
+	}
+
+	if err := migrationApplied("1-slow-query.sql"); err == nil {
+		t.Error("Expected the interrupted migration to be rolled back")
+	}
+}
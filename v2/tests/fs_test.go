@@ -0,0 +1,66 @@
+package tests_test
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+//go:embed sql_embedded/*.sql
+var embedded embed.FS
+
+// Can migrations be read from an fs.FS?
+func TestFSReader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"db/migrations/1-create-users.sql":   {Data: []byte("--- !Up\ncreate table users (id serial);\n\n--- !Down\ndrop table users;\n")},
+		"db/migrations/2-create-roles.sql":   {Data: []byte("--- !Up\ncreate table roles (id serial);\n\n--- !Down\ndrop table roles;\n")},
+		"db/migrations/README.md":            {Data: []byte("Migrations")},
+		"db/migrations/old/3-create-acl.sql": {Data: []byte("--- !Up\n\n--- !Down\n\n")},
+	}
+
+	options := migrations.WithFS(fsys).WithDirectory("./db/migrations")
+
+	available, err := options.Available(migrations.Up)
+	if err != nil {
+		t.Fatalf("Unable to list migrations: %s", err)
+	}
+
+	if strings.Join(available, ",") != "1-create-users.sql,2-create-roles.sql" {
+		t.Errorf("Expected the two migrations in the directory; got %v", available)
+	}
+
+	if revision := options.LatestRevision(); revision != 2 {
+		t.Errorf("Expected latest revision 2; got %d", revision)
+	}
+
+	if err := options.Create("create-acl"); !errors.Is(err, migrations.ErrReadOnly) {
+		t.Errorf("Expected creating a migration to fail as read-only; got %v", err)
+	}
+
+	sub, err := fs.Sub(fsys, "db/migrations")
+	if err != nil {
+		t.Fatalf("Unable to get the migrations sub-directory: %s", err)
+	}
+
+	if revision := migrations.WithFS(sub).WithDirectory(".").LatestRevision(); revision != 2 {
+		t.Errorf("Expected latest revision 2 from the sub-directory; got %d", revision)
+	}
+}
+
+// Can migrations embedded in the binary be applied?
+func TestEmbedFS(t *testing.T) {
+	defer clean(t)
+
+	if err := migrations.WithFS(embedded).WithDirectory("sql_embedded").Apply(conn); err != nil {
+		t.Fatalf("Unable to apply embedded migrations: %s", err)
+	}
+
+	if err := tableExists("roles"); err != nil {
+		t.Errorf("Expected the roles table to be created: %s", err)
+	}
+}
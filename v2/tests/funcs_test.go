@@ -0,0 +1,104 @@
+package tests_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Are Go migrations merged into the order of the SQL migrations?
+func TestRegisterOrder(t *testing.T) {
+	options := migrations.WithDirectory("./sql_embedded").WithRegistry(seedUsers())
+
+	available, err := options.Available(migrations.Up)
+	if err != nil {
+		t.Fatalf("Unable to list the migrations: %s", err)
+	}
+
+	if strings.Join(available, ",") != "1-create-users.sql,2-create-roles.sql,3-seed-users.go" {
+		t.Errorf("Unexpected migrations: %v", available)
+	}
+
+	if latest := options.LatestRevision(); latest != 3 {
+		t.Errorf("Expected revision 3; got %d", latest)
+	}
+}
+
+// Are Go migrations applied, tracked, and rolled back like SQL migrations?
+func TestRegister(t *testing.T) {
+	defer clean(t)
+
+	options := migrations.WithDirectory("./sql_embedded").WithRegistry(seedUsers())
+	if err := options.Apply(conn); err != nil {
+		t.Fatalf("Unable to apply migrations: %s", err)
+	}
+
+	if err := migrationApplied("3-seed-users.go"); err != nil {
+		t.Fatalf("Expected the Go migration to be applied: %s", err)
+	}
+
+	if users := countUsers(t); users != 1 {
+		t.Errorf("Expected the Go migration to add a user; got %d", users)
+	}
+
+	if err := options.WithRevision(2).Apply(conn); err != nil {
+		t.Fatalf("Unable to roll back the Go migration: %s", err)
+	}
+
+	if err := migrationApplied("3-seed-users.go"); err == nil {
+		t.Errorf("Expected the Go migration to be rolled back")
+	}
+
+	if users := countUsers(t); users != 0 {
+		t.Errorf("Expected the Go migration to remove the user; got %d", users)
+	}
+}
+
+// Does rolling back a Go migration the application no longer registers fail?
+func TestRegisterMissing(t *testing.T) {
+	defer clean(t)
+
+	options := migrations.WithDirectory("./sql_embedded")
+	if err := options.WithRegistry(seedUsers()).Apply(conn); err != nil {
+		t.Fatalf("Unable to apply migrations: %s", err)
+	}
+
+	err := options.WithRegistry(migrations.NewRegistry()).Apply(conn)
+	if !errors.Is(err, migrations.ErrNotRegistered) {
+		t.Fatalf("Expected the Go migration not to be registered; got %v", err)
+	}
+
+	if err := migrationApplied("3-seed-users.go"); err != nil {
+		t.Errorf("Expected the Go migration to remain applied: %s", err)
+	}
+}
+
+// Returns a registry with a Go migration that adds a user.
+func seedUsers() *migrations.Registry {
+	registry := migrations.NewRegistry()
+	registry.Register(3, "seed-users",
+		func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "insert into users (email, username) values ('admin@example.com', 'admin')")
+			return err
+		},
+		func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "delete from users where username = 'admin'")
+			return err
+		})
+
+	return registry
+}
+
+// Returns the number of users in the database.
+func countUsers(t *testing.T) int {
+	var count int
+	if err := conn.QueryRow("select count(*) from users").Scan(&count); err != nil {
+		t.Fatalf("Unable to count the users: %s", err)
+	}
+
+	return count
+}
@@ -0,0 +1,70 @@
+package tests_test
+
+import (
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Does the history keep a record of migrations applied, rolled back, and failed?
+func TestHistory(t *testing.T) {
+	defer clean(t)
+
+	if err := migrate(2); err != nil {
+		t.Fatalf("Unable to run migration to revision 2: %s", err)
+	}
+
+	if err := migrate(1); err != nil {
+		t.Fatalf("Unable to roll back to revision 1: %s", err)
+	}
+
+	if err := migrate(3); err == nil {
+		t.Fatal("Expected migration 3 to fail")
+	}
+
+	history, err := migrations.History(conn, migrations.HistoryFilter{Migration: "2-add-email-to-sample.sql"})
+	if err != nil {
+		t.Fatalf("Unable to get the migrations history: %s", err)
+	}
+
+	var events []migrations.HistoryEvent
+	for _, record := range history {
+		events = append(events, record.Event)
+	}
+
+	expected := []migrations.HistoryEvent{migrations.HistoryApply, migrations.HistoryRollback, migrations.HistoryApply}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected events %v; got %v", expected, events)
+	}
+
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("Expected events %v; got %v", expected, events)
+			break
+		}
+	}
+
+	if history[0].Checksum == "" || history[1].Checksum != history[0].Checksum {
+		t.Errorf("Expected the checksum to be recorded with the apply and rollback")
+	}
+
+	failures, err := migrations.History(conn, migrations.HistoryFilter{
+		Events: []migrations.HistoryEvent{migrations.HistoryFailure},
+	})
+	if err != nil {
+		t.Fatalf("Unable to get the failures: %s", err)
+	}
+
+	if len(failures) != 1 || failures[0].Migration != "3-sample-data.sql" || failures[0].Error == "" {
+		t.Errorf("Expected the failure of 3-sample-data.sql to be recorded; got %+v", failures)
+	}
+
+	latest, err := migrations.History(conn, migrations.HistoryFilter{Limit: 1})
+	if err != nil {
+		t.Fatalf("Unable to get the latest event: %s", err)
+	}
+
+	if len(latest) != 1 || latest[0].Event != migrations.HistoryFailure {
+		t.Errorf("Expected the latest event to be the failure; got %+v", latest)
+	}
+}
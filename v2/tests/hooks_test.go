@@ -0,0 +1,80 @@
+package tests_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Are the hooks and callback files run around the migrations, in order?
+func TestHooks(t *testing.T) {
+	defer clean(t)
+
+	var calls []string
+	record := func(name string) migrations.Hook {
+		return func(ctx context.Context, tx *sql.Tx, migration string, direction migrations.Direction) error {
+			calls = append(calls, name+" "+migration)
+			return nil
+		}
+	}
+
+	options := migrations.WithDirectory("./sql_hooks").WithHooks(migrations.Hooks{
+		BeforeAll:  record("beforeAll"),
+		BeforeEach: record("beforeEach"),
+		AfterEach:  record("afterEach"),
+		AfterAll:   record("afterAll"),
+	})
+
+	if err := options.Apply(conn); err != nil {
+		t.Fatalf("Unable to apply the migrations: %s", err)
+	}
+
+	expected := []string{
+		"beforeAll ",
+		"beforeEach 1-create-accounts.sql",
+		"afterEach 1-create-accounts.sql",
+		"beforeEach 2-index-accounts.sql",
+		"afterEach 2-index-accounts.sql",
+		"afterAll ",
+	}
+
+	if strings.Join(calls, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("Expected hooks %v; got %v", expected, calls)
+	}
+
+	var found int
+	if err := conn.QueryRow("select count(*) from callbacks").Scan(&found); err != nil {
+		t.Fatalf("Expected afterMigrate.sql to run: %s", err)
+	}
+
+	if found != 1 {
+		t.Errorf("Expected afterMigrate.sql to run once; ran %d times", found)
+	}
+
+	if err := migrationApplied(migrations.AfterMigrate); err == nil {
+		t.Error("Callback files shouldn't be recorded as migrations")
+	}
+}
+
+// Does a failed migration call the OnError hook?
+func TestHooksOnError(t *testing.T) {
+	defer clean(t)
+
+	var failed string
+	options := migrations.WithDirectory("./sql_broken").WithHooks(migrations.Hooks{
+		OnError: func(ctx context.Context, tx *sql.Tx, migration string, direction migrations.Direction, err error) {
+			failed = migration
+		},
+	})
+
+	if err := options.Apply(conn); err == nil {
+		t.Fatal("Expected the broken migration to fail")
+	}
+
+	if failed != "1-broken.sql" {
+		t.Errorf("Expected OnError for 1-broken.sql; got %q", failed)
+	}
+}
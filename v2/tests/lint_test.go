@@ -0,0 +1,47 @@
+package tests_test
+
+import (
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Does Lint flag the risky operations, with the file, line, and rule?
+func TestLint(t *testing.T) {
+	findings, err := migrations.Lint(migrations.WithDirectory("./sql_lint"))
+	if err != nil {
+		t.Fatalf("Unable to lint the migrations: %s", err)
+	}
+
+	expected := []migrations.Finding{
+		{File: "1-create-accounts.sql", Line: 3, Rule: migrations.LintIndexConcurrently},
+		{File: "2-alter-accounts.sql", Line: 2, Rule: migrations.LintNotNullWithoutDefault},
+		{File: "2-alter-accounts.sql", Line: 4, Rule: migrations.LintAlterColumnType},
+		{File: "2-alter-accounts.sql", Line: 5, Rule: migrations.LintDropWithoutStop},
+		{File: "2-alter-accounts.sql", Line: 7, Rule: migrations.LintEmptyDown},
+		{File: "3-missing-up.sql", Line: 1, Rule: migrations.LintMissingUp},
+	}
+
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %d findings; got %v", len(expected), findings)
+	}
+
+	for idx, finding := range findings {
+		want := expected[idx]
+		if finding.File != want.File || finding.Line != want.Line || finding.Rule != want.Rule {
+			t.Errorf("Expected %s:%d: %s; got %s", want.File, want.Line, want.Rule, finding)
+		}
+	}
+}
+
+// Do suppression comments hide the findings?
+func TestLintSuppressed(t *testing.T) {
+	findings, err := migrations.Lint(migrations.WithDirectory("./sql_lint_suppressed"))
+	if err != nil {
+		t.Fatalf("Unable to lint the migrations: %s", err)
+	}
+
+	if len(findings) != 0 {
+		t.Errorf("Expected the findings to be suppressed; got %v", findings)
+	}
+}
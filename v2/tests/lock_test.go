@@ -0,0 +1,97 @@
+package tests_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Does the advisory lock keep a second instance from migrating?
+func TestLock(t *testing.T) {
+	defer clean(t)
+
+	lock, err := migrations.Lock(conn, migrations.DefaultLockKey, 0)
+	if err != nil {
+		t.Fatalf("Unable to acquire the migrations lock: %s", err)
+	}
+
+	err = migrations.WithRevision(2).WithLockTimeout(500 * time.Millisecond).Apply(conn)
+	if !errors.Is(err, migrations.ErrLockTimeout) {
+		t.Errorf("Expected migrations to time out waiting for the lock; got %v", err)
+	}
+
+	if err := migrations.Unlock(lock, migrations.DefaultLockKey); err != nil {
+		t.Fatalf("Unable to release the migrations lock: %s", err)
+	}
+
+	if err := migrations.WithRevision(2).WithLockTimeout(500 * time.Millisecond).Apply(conn); err != nil {
+		t.Errorf("Expected migrations to run once the lock was released: %s", err)
+	}
+}
+
+// Can a stale lock be broken?
+func TestForceUnlock(t *testing.T) {
+	if _, err := migrations.Lock(conn, migrations.DefaultLockKey, 0); err != nil {
+		t.Fatalf("Unable to acquire the migrations lock: %s", err)
+	}
+
+	terminated, err := migrations.ForceUnlock(conn, migrations.DefaultLockKey)
+	if err != nil {
+		t.Fatalf("Unable to force the lock open: %s", err)
+	}
+
+	if terminated != 1 {
+		t.Errorf("Expected to terminate one session; terminated %d", terminated)
+	}
+
+	lock, err := migrations.Lock(conn, migrations.DefaultLockKey, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected to acquire the lock after forcing it open: %s", err)
+	}
+
+	_ = migrations.Unlock(lock, migrations.DefaultLockKey)
+}
+
+// Does Apply run on the connection holding the lock, so a single-connection pool doesn't deadlock?
+func TestLockSingleConnection(t *testing.T) {
+	defer clean(t)
+
+	single, err := sql.Open("pgx", "postgres://postgres@localhost/migrations_test?sslmode=disable")
+	if err != nil {
+		t.Fatalf("Unable to connect to the database: %s", err)
+	}
+	defer func() {
+		_ = single.Close()
+	}()
+
+	single.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := migrations.WithRevision(2).ApplyContext(ctx, single); err != nil {
+		t.Fatalf("Unable to apply the migrations with a single connection: %s", err)
+	}
+}
+
+// Can a lock with a negative key, such as a tenant's lock, be broken?
+func TestForceUnlockNegativeKey(t *testing.T) {
+	const key int64 = -0x6d696772617465
+
+	if _, err := migrations.Lock(conn, key, 0); err != nil {
+		t.Fatalf("Unable to acquire the migrations lock: %s", err)
+	}
+
+	terminated, err := migrations.ForceUnlock(conn, key)
+	if err != nil {
+		t.Fatalf("Unable to force the lock open: %s", err)
+	}
+
+	if terminated != 1 {
+		t.Errorf("Expected to terminate one session; terminated %d", terminated)
+	}
+}
@@ -0,0 +1,79 @@
+package tests_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Do MarkApplied and Unmark change the tracking tables without running the migrations?
+func TestMarkApplied(t *testing.T) {
+	defer clean(t)
+
+	options := migrations.WithDirectory("./sql")
+	if err := migrations.MarkApplied(conn, options, 1); err != nil {
+		t.Fatalf("Unable to mark revision 1 as applied: %s", err)
+	}
+
+	if err := migrationApplied("1-create-sample.sql"); err != nil {
+		t.Errorf("Expected 1-create-sample.sql to be marked as applied: %s", err)
+	}
+
+	if err := tableExists("samples"); err == nil {
+		t.Error("Expected the migration not to run")
+	}
+
+	if err := migrations.Unmark(conn, options, 1); err != nil {
+		t.Fatalf("Unable to unmark revision 1: %s", err)
+	}
+
+	if err := migrationApplied("1-create-sample.sql"); err == nil {
+		t.Error("Expected 1-create-sample.sql to be unmarked")
+	}
+
+	var found bool
+	if err := conn.QueryRow("select exists(select 1 from migrations.rollbacks)").Scan(&found); err != nil {
+		t.Fatalf("Unable to query the rollbacks: %s", err)
+	} else if found {
+		t.Error("Expected the rollback to be removed")
+	}
+
+	if err := migrations.Unmark(conn, options, 1); !errors.Is(err, migrations.ErrUnknownRevision) {
+		t.Errorf("Expected unmarking an unapplied revision to fail; got %v", err)
+	}
+
+	if err := migrations.MarkApplied(conn, options, 99); !errors.Is(err, migrations.ErrUnknownRevision) {
+		t.Errorf("Expected marking a missing revision to fail; got %v", err)
+	}
+}
+
+// Does ForceRevision mark and unmark the migrations around the revision, and clear dirty ones?
+func TestForceRevision(t *testing.T) {
+	defer clean(t)
+
+	if err := migrate(1); err != nil {
+		t.Fatalf("Unable to run migration to revision 1: %s", err)
+	}
+
+	if _, err := conn.Exec("insert into migrations.applied (migration, dirty) values ('3-sample-data.sql', true)"); err != nil {
+		t.Fatalf("Unable to mark a migration dirty: %s", err)
+	}
+
+	options := migrations.WithDirectory("./sql")
+	if err := migrations.ForceRevision(conn, options, 2); err != nil {
+		t.Fatalf("Unable to force revision 2: %s", err)
+	}
+
+	if err := migrationApplied("2-add-email-to-sample.sql"); err != nil {
+		t.Errorf("Expected 2-add-email-to-sample.sql to be marked as applied: %s", err)
+	}
+
+	if err := migrationApplied("3-sample-data.sql"); err == nil {
+		t.Error("Expected the dirty 3-sample-data.sql to be unmarked")
+	}
+
+	if err := migrate(2); err != nil {
+		t.Errorf("Expected the database to be clean: %s", err)
+	}
+}
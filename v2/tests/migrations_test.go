@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -312,7 +313,7 @@ func TestTransactions(t *testing.T) {
 }
 
 // Shortcut to run the test migrations in the sql directory.
-func migrate(revision int) error {
+func migrate(revision int64) error {
 	return migrations.WithRevision(revision).Apply(conn)
 }
 
@@ -330,6 +331,18 @@ func clean(t *testing.T) {
 		}
 	}
 
+	if err := tableExists("migrations.applied_async"); err == nil {
+		if _, err := conn.Exec("delete from migrations.applied_async"); err != nil {
+			t.Fatalf("Unable to clear the migrations.applied_async table: %s", err)
+		}
+	}
+
+	if err := tableExists("migrations.history"); err == nil {
+		if _, err := conn.Exec("delete from migrations.history"); err != nil {
+			t.Fatalf("Unable to clear the migrations.history table: %s", err)
+		}
+	}
+
 	rows, err := conn.Query("select table_name from information_schema.tables where table_schema='public'")
 	if err != nil {
 		t.Fatalf("Couldn't query for table names: %s", err)
@@ -348,6 +361,30 @@ func clean(t *testing.T) {
 	}
 }
 
+// Copies the migration files in the test directory to a temporary directory, for tests that add,
+// rename, or modify files.
+func copyMigrations(t *testing.T, directory string) string {
+	dir := t.TempDir()
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatalf("Unable to read %s: %s", directory, err)
+	}
+
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(directory, entry.Name()))
+		if err != nil {
+			t.Fatalf("Unable to read %s: %s", entry.Name(), err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, entry.Name()), data, 0644); err != nil {
+			t.Fatalf("Unable to write %s: %s", entry.Name(), err)
+		}
+	}
+
+	return dir
+}
+
 // Check if the table exists.  Returns nil if the table exists.
 func tableExists(table string) error {
 	parts := strings.Split(table, ".")
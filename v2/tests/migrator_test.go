@@ -0,0 +1,88 @@
+package tests_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// recorder is a Logger that counts the messages logged.
+type recorder struct {
+	sync.Mutex
+	messages []string
+}
+
+func (r *recorder) Debugf(format string, args ...interface{}) {
+	r.Infof(format, args...)
+}
+
+func (r *recorder) Infof(format string, args ...interface{}) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) count() int {
+	r.Lock()
+	defer r.Unlock()
+
+	return len(r.messages)
+}
+
+// Do migrators with different readers and loggers interfere with one another?
+func TestMigrator(t *testing.T) {
+	global := new(recorder)
+
+	saved := migrations.Log
+	migrations.Log = global
+	defer func() {
+		migrations.Log = saved
+	}()
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 8; i++ {
+		wg.Add(1)
+
+		go func(revisions int) {
+			defer wg.Done()
+
+			fsys := make(fstest.MapFS)
+			for rev := 1; rev <= revisions; rev++ {
+				name := fmt.Sprintf("sql/%d-migration.sql", rev)
+				fsys[name] = &fstest.MapFile{Data: []byte("--- !Up\n\n--- !Down\n\n")}
+			}
+
+			fsys["invalid/latest-migration.sql"] = &fstest.MapFile{Data: []byte("--- !Up\n\n--- !Down\n\n")}
+
+			logger := new(recorder)
+			m := migrations.NewMigrator(migrations.WithFS(fsys).WithDirectory("sql").WithLogger(logger))
+
+			for n := 0; n < 100; n++ {
+				if latest := m.LatestRevision(); latest != int64(revisions) {
+					t.Errorf("Expected revision %d; got %d", revisions, latest)
+					return
+				}
+			}
+
+			// Invalid migrations are logged to the migrator's logger
+			invalid := migrations.NewMigrator(migrations.WithFS(fsys).WithDirectory("invalid").WithLogger(logger))
+			if latest := invalid.LatestRevision(); latest != 0 {
+				t.Errorf("Expected no revision from an invalid migration; got %d", latest)
+			}
+
+			if logger.count() != 1 {
+				t.Errorf("Expected the migrator to log one message; got %d", logger.count())
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	if global.count() != 0 {
+		t.Errorf("Expected nothing logged to the package logger; got %v", global.messages)
+	}
+}
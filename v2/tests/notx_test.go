@@ -0,0 +1,66 @@
+package tests_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Do /notx migrations run statements that can't run in a transaction?
+func TestNoTx(t *testing.T) {
+	defer clean(t)
+
+	dir := "./sql_notx"
+	if err := migrations.WithDirectory(dir).Apply(conn); err != nil {
+		t.Fatalf("Unable to apply the migrations: %s", err)
+	}
+
+	if err := migrationApplied("2-index-accounts.sql"); err != nil {
+		t.Fatalf("Expected the /notx migration to be applied: %s", err)
+	}
+
+	var exists bool
+	if err := conn.QueryRow("select exists(select 1 from pg_indexes where indexname = 'idx_accounts_name')").Scan(&exists); err != nil || !exists {
+		t.Fatalf("Expected the index to be created concurrently: %v", err)
+	}
+
+	if err := migrations.WithDirectory(dir).WithRevision(1).Apply(conn); err != nil {
+		t.Fatalf("Unable to roll back the /notx migration: %s", err)
+	}
+
+	if err := migrationApplied("2-index-accounts.sql"); err == nil {
+		t.Errorf("Expected the /notx migration to be rolled back")
+	}
+}
+
+// Is the database marked dirty when a /notx migration fails partway?
+func TestNoTxDirty(t *testing.T) {
+	defer clean(t)
+
+	options := migrations.WithDirectory("./sql_notx_dirty")
+
+	err := options.Apply(conn)
+	if !errors.Is(err, migrations.ErrDirty) {
+		t.Fatalf("Expected the database to be dirty; got %v", err)
+	}
+
+	var dirty *migrations.DirtyError
+	if !errors.As(err, &dirty) || dirty.Migration != "3-broken.sql" || dirty.Statement != "select * from missing_table" {
+		t.Errorf("Expected the failed statement in 3-broken.sql; got %v", err)
+	}
+
+	// Later runs refuse to continue
+	if err := options.Apply(conn); !errors.Is(err, migrations.ErrDirty) {
+		t.Errorf("Expected the dirty database to stop the migrations; got %v", err)
+	}
+
+	statuses, err := options.Status(conn)
+	if err != nil {
+		t.Fatalf("Unable to get the migrations status: %s", err)
+	}
+
+	if statuses[2].State != migrations.StateDirty {
+		t.Errorf("Expected 3-broken.sql to be dirty; got %s", statuses[2].State)
+	}
+}
@@ -0,0 +1,45 @@
+package tests_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Are migrations that arrive after higher revisions were applied caught?
+func TestOutOfOrder(t *testing.T) {
+	defer clean(t)
+
+	options := migrations.WithDirectory("./sql_embedded")
+	if err := options.Apply(conn); err != nil {
+		t.Fatalf("Unable to apply migrations: %s", err)
+	}
+
+	// As if 1-create-users.sql was merged after 2-create-roles.sql was applied
+	if _, err := conn.Exec("delete from migrations.applied where migration = '1-create-users.sql'"); err != nil {
+		t.Fatalf("Unable to remove the first migration: %s", err)
+	}
+
+	err := options.WithOutOfOrderPolicy(migrations.Error).Apply(conn)
+	if !errors.Is(err, migrations.ErrOutOfOrder) {
+		t.Fatalf("Expected the migrations to be out of order; got %v", err)
+	}
+
+	var late *migrations.OutOfOrderError
+	if !errors.As(err, &late) {
+		t.Fatalf("Expected an *OutOfOrderError; got %T", err)
+	}
+
+	if late.Latest != "2-create-roles.sql" {
+		t.Errorf("Expected the latest migration to be 2-create-roles.sql; got %s", late.Latest)
+	}
+
+	if len(late.Revisions) != 1 || late.Revisions[0] != 1 {
+		t.Errorf("Expected revision 1 to be out of order; got %v", late.Revisions)
+	}
+
+	if err := migrationApplied("1-create-users.sql"); err == nil {
+		t.Errorf("Expected the late migration not to be applied")
+	}
+}
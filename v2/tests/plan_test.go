@@ -0,0 +1,177 @@
+package tests_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Does the plan match what Apply would do, without changing anything?
+func TestPlan(t *testing.T) {
+	defer clean(t)
+
+	steps, err := migrations.WithRevision(2).Plan(conn)
+	if err != nil {
+		t.Fatalf("Unable to plan migrations: %s", err)
+	}
+
+	if len(steps) != 2 {
+		t.Fatalf("Expected 2 steps; got %d", len(steps))
+	}
+
+	for idx, expected := range []string{"1-create-sample.sql", "2-add-email-to-sample.sql"} {
+		if steps[idx].Migration != expected || steps[idx].Direction != migrations.Up {
+			t.Errorf("Expected step %d to be %s up; got %s", idx, expected, steps[idx])
+		}
+	}
+
+	if err := migrate(2); err != nil {
+		t.Fatalf("Unable to run migration to revision 2: %s", err)
+	}
+
+	steps, err = migrations.WithRevision(1).Plan(conn)
+	if err != nil {
+		t.Fatalf("Unable to plan rollback: %s", err)
+	}
+
+	if len(steps) != 1 || steps[0].Migration != "2-add-email-to-sample.sql" || steps[0].Direction != migrations.Down {
+		t.Fatalf("Expected to roll back 2-add-email-to-sample.sql; got %v", steps)
+	}
+
+	if steps[0].Source != migrations.FromFile {
+		t.Errorf("Expected the rollback to come from the file; got %s", steps[0].Source)
+	}
+
+	if err := migrationApplied("2-add-email-to-sample.sql"); err != nil {
+		t.Errorf("Expected planning to leave the database unchanged: %s", err)
+	}
+}
+
+// Does the plan flag /async migrations, and skip them while they're running elsewhere?
+func TestPlanAsync(t *testing.T) {
+	defer clean(t)
+
+	stuckAsync(t)
+	options := migrations.WithDirectory("./sql_async")
+
+	steps, err := options.Plan(conn)
+	if err != nil {
+		t.Fatalf("Unable to plan migrations: %s", err)
+	}
+
+	if len(steps) != 0 {
+		t.Errorf("Expected the running migration to be skipped; got %v", steps)
+	}
+
+	steps, err = options.WithAsyncTimeout(time.Minute).Plan(conn)
+	if err != nil {
+		t.Fatalf("Unable to plan migrations: %s", err)
+	}
+
+	if len(steps) != 1 || steps[0].Migration != "2-backfill-accounts.sql" || !steps[0].Async {
+		t.Errorf("Expected the abandoned migration to be retried asynchronously; got %v", steps)
+	}
+}
+
+// Does the plan include the callback files where Apply would run them?
+func TestPlanCallbacks(t *testing.T) {
+	defer clean(t)
+
+	steps, err := migrations.WithDirectory("./sql_hooks").Plan(conn)
+	if err != nil {
+		t.Fatalf("Unable to plan migrations: %s", err)
+	}
+
+	expected := []string{
+		migrations.BeforeEachMigrate,
+		"1-create-accounts.sql",
+		migrations.BeforeEachMigrate,
+		"2-index-accounts.sql",
+		migrations.AfterMigrate,
+	}
+
+	if len(steps) != len(expected) {
+		t.Fatalf("Expected %d steps; got %v", len(expected), steps)
+	}
+
+	for idx, step := range steps {
+		if step.Migration != expected[idx] {
+			t.Errorf("Expected step %d to be %s; got %s", idx, expected[idx], step)
+		}
+
+		// Every other step is a callback
+		if (step.Source == migrations.FromCallback) != (idx%2 == 0) {
+			t.Errorf("Unexpected source for step %d: %s", idx, step)
+		}
+	}
+}
+
+// Does the plan work with a single-connection pool?
+func TestPlanSingleConnection(t *testing.T) {
+	defer clean(t)
+
+	if err := migrate(2); err != nil {
+		t.Fatalf("Unable to run migration to revision 2: %s", err)
+	}
+
+	single, err := sql.Open("pgx", "postgres://postgres@localhost/migrations_test?sslmode=disable")
+	if err != nil {
+		t.Fatalf("Unable to connect to the database: %s", err)
+	}
+	defer func() {
+		_ = single.Close()
+	}()
+
+	single.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	steps, err := migrations.WithRevision(1).PlanContext(ctx, single)
+	if err != nil {
+		t.Fatalf("Unable to plan with a single connection: %s", err)
+	}
+
+	if len(steps) != 1 || steps[0].Direction != migrations.Down {
+		t.Errorf("Expected to roll back 2-add-email-to-sample.sql; got %v", steps)
+	}
+}
+
+// Does the plan fail the same way Apply would?
+func TestPlanChecks(t *testing.T) {
+	defer clean(t)
+
+	options := migrations.WithDirectory("./sql_embedded")
+	if err := options.Apply(conn); err != nil {
+		t.Fatalf("Unable to apply migrations: %s", err)
+	}
+
+	// As if 1-create-users.sql was merged after 2-create-roles.sql was applied
+	if _, err := conn.Exec("delete from migrations.applied where migration = '1-create-users.sql'"); err != nil {
+		t.Fatalf("Unable to remove the first migration: %s", err)
+	}
+
+	if _, err := options.WithOutOfOrderPolicy(migrations.Error).Plan(conn); !errors.Is(err, migrations.ErrOutOfOrder) {
+		t.Errorf("Expected the migrations to be out of order; got %v", err)
+	}
+
+	if _, err := conn.Exec("update migrations.applied set checksum = 'modified' where migration = '2-create-roles.sql'"); err != nil {
+		t.Fatalf("Unable to modify the checksum: %s", err)
+	}
+
+	if _, err := options.Plan(conn); !errors.Is(err, migrations.ErrChecksumMismatch) {
+		t.Errorf("Expected a checksum mismatch; got %v", err)
+	}
+
+	if _, err := conn.Exec("update migrations.applied set dirty = true where migration = '2-create-roles.sql'"); err != nil {
+		t.Fatalf("Unable to mark the migration dirty: %s", err)
+	}
+
+	if _, err := options.Plan(conn); !errors.Is(err, migrations.ErrDirty) {
+		t.Errorf("Expected the database to be dirty; got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package tests_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Are stale rollbacks detected, and refreshed by Apply according to the policy?
+func TestRefreshRollbacks(t *testing.T) {
+	defer clean(t)
+
+	if err := migrations.WithDirectory("./sql_refresh_stale").Apply(conn); err != nil {
+		t.Fatalf("Unable to apply the migrations: %s", err)
+	}
+
+	// The buggy rollback fixed after the migration shipped
+	options := migrations.WithDirectory("./sql_refresh")
+	stale, err := migrations.StaleRollbacks(conn, options)
+	if err != nil {
+		t.Fatalf("Unable to compare the stored rollbacks: %s", err)
+	}
+
+	if len(stale) != 1 || stale[0].Migration != "1-create-widgets.sql" {
+		t.Fatalf("Expected 1-create-widgets.sql to be stale; got %v", stale)
+	}
+
+	if err := options.RefreshRollbacks(migrations.Error).Apply(conn); !errors.Is(err, migrations.ErrRollbackDrift) {
+		t.Fatalf("Expected the Error policy to fail with ErrRollbackDrift; got %v", err)
+	}
+
+	if err := options.RefreshRollbacks(migrations.Allow).Apply(conn); err != nil {
+		t.Fatalf("Unable to apply the migrations: %s", err)
+	}
+
+	var down string
+	if err := conn.QueryRow("select down from migrations.rollbacks where migration = '1-create-widgets.sql'").Scan(&down); err != nil {
+		t.Fatalf("Unable to query the stored rollback: %s", err)
+	}
+
+	if down != "drop table widgets;" {
+		t.Errorf("Expected the stored rollback to be refreshed; got %q", down)
+	}
+
+	if stale, err := migrations.StaleRollbacks(conn, options); err != nil || len(stale) != 0 {
+		t.Errorf("Expected no stale rollbacks; got %v, %v", stale, err)
+	}
+}
+
+// Does the diff show the lines removed from and added to the rollback?
+func TestRollbackDriftDiff(t *testing.T) {
+	drift := &migrations.RollbackDriftError{
+		Migration: "1-create-widgets.sql",
+		Stored:    "drop index widgets_name;\ndrop tabel widgets;",
+		Current:   "drop index widgets_name;\ndrop table widgets;",
+	}
+
+	expected := "  drop index widgets_name;\n- drop tabel widgets;\n+ drop table widgets;\n"
+	if diff := drift.Diff(); diff != expected {
+		t.Errorf("Expected diff:\n%s\ngot:\n%s", expected, diff)
+	}
+}
@@ -0,0 +1,93 @@
+package tests_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Are migration files sharing a revision number caught?
+func TestDuplicateRevision(t *testing.T) {
+	dir := copyMigrations(t, "./sql_duplicate")
+
+	err := migrations.WithDirectory(dir).Create("create-acl")
+	if !errors.Is(err, migrations.ErrDuplicateRevision) {
+		t.Fatalf("Expected a duplicate revision error; got %v", err)
+	}
+
+	var duplicate *migrations.DuplicateRevisionError
+	if !errors.As(err, &duplicate) {
+		t.Fatalf("Expected a *DuplicateRevisionError; got %T", err)
+	}
+
+	if strings.Join(duplicate.Duplicates[2], ",") != "2-create-groups.sql,2-create-roles.sql" {
+		t.Errorf("Expected the colliding files for revision 2; got %v", duplicate.Duplicates)
+	}
+}
+
+// Are the newer colliding files renumbered?
+func TestRenumber(t *testing.T) {
+	defer clean(t)
+
+	dir := copyMigrations(t, "./sql_duplicate")
+	options := migrations.WithDirectory(dir)
+
+	if err := options.Apply(conn); !errors.Is(err, migrations.ErrDuplicateRevision) {
+		t.Fatalf("Expected a duplicate revision error; got %v", err)
+	}
+
+	// As if 2-create-roles.sql was applied before the branches were merged
+	if err := migrations.InitializeDB(conn, dir); err != nil {
+		t.Fatalf("Unable to initialize the database: %s", err)
+	}
+
+	if _, err := conn.Exec("insert into migrations.applied (migration) values ('2-create-roles.sql')"); err != nil {
+		t.Fatalf("Unable to record the applied migration: %s", err)
+	}
+
+	renamed, err := options.Renumber(conn)
+	if err != nil {
+		t.Fatalf("Unable to renumber the migrations: %s", err)
+	}
+
+	if len(renamed) != 1 || renamed[0].From != "2-create-groups.sql" || renamed[0].To != "4-create-groups.sql" {
+		t.Errorf("Expected 2-create-groups.sql to be renumbered to 4; got %+v", renamed)
+	}
+
+	available, err := options.Available(migrations.Up)
+	if err != nil {
+		t.Fatalf("Unable to list the migrations: %s", err)
+	}
+
+	if strings.Join(available, ",") != "1-create-users.sql,2-create-roles.sql,3-create-acl.sql,4-create-groups.sql" {
+		t.Errorf("Unexpected migrations after renumbering: %v", available)
+	}
+}
+
+// If none of the colliding files have been applied, does the first by name keep its revision?
+func TestRenumberNothingApplied(t *testing.T) {
+	defer clean(t)
+
+	dir := copyMigrations(t, "./sql_duplicate")
+	options := migrations.WithDirectory(dir)
+
+	renamed, err := options.Renumber(conn)
+	if err != nil {
+		t.Fatalf("Unable to renumber the migrations: %s", err)
+	}
+
+	if len(renamed) != 1 || renamed[0].From != "2-create-roles.sql" || renamed[0].To != "4-create-roles.sql" {
+		t.Errorf("Expected 2-create-roles.sql to be renumbered to 4; got %+v", renamed)
+	}
+
+	available, err := options.Available(migrations.Up)
+	if err != nil {
+		t.Fatalf("Unable to list the migrations: %s", err)
+	}
+
+	if strings.Join(available, ",") != "1-create-users.sql,2-create-groups.sql,3-create-acl.sql,4-create-roles.sql" {
+		t.Errorf("Unexpected migrations after renumbering: %v", available)
+	}
+}
@@ -0,0 +1,50 @@
+package tests_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Does a failed migration leave none of the pending migrations applied?
+func TestSingleTransaction(t *testing.T) {
+	defer clean(t)
+
+	if err := migrations.WithDirectory("./sql_single_broken").SingleTransaction().Apply(conn); err == nil {
+		t.Fatal("Expected the broken migration to fail")
+	}
+
+	for _, migration := range []string{"1-create-accounts.sql", "2-index-accounts.sql"} {
+		if err := migrationApplied(migration); err == nil {
+			t.Errorf("Expected %s to be rolled back with the broken migration", migration)
+		}
+	}
+
+	if err := tableExists("accounts"); err == nil {
+		t.Errorf("Expected the accounts table to be rolled back")
+	}
+
+	// Fixed, all the migrations are applied together
+	if err := migrations.WithDirectory("./sql_single").SingleTransaction().Apply(conn); err != nil {
+		t.Fatalf("Unable to apply the migrations: %s", err)
+	}
+
+	if err := migrationApplied("3-broken.sql"); err != nil {
+		t.Errorf("Expected the migrations to be applied: %s", err)
+	}
+}
+
+// Are /notx migrations rejected in a single transaction?
+func TestSingleTransactionNoTx(t *testing.T) {
+	defer clean(t)
+
+	err := migrations.WithDirectory("./sql_notx").SingleTransaction().Apply(conn)
+	if !errors.Is(err, migrations.ErrSingleTransaction) {
+		t.Fatalf("Expected the /notx migration to be rejected; got %v", err)
+	}
+
+	if err := migrationApplied("1-create-accounts.sql"); err == nil {
+		t.Errorf("Expected no migrations to be applied")
+	}
+}
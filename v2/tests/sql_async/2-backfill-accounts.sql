@@ -0,0 +1,5 @@
+--- !Up /async
+insert into accounts (name) values ('alice'), ('bob');
+
+--- !Down
+delete from accounts;
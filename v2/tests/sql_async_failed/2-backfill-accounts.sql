@@ -0,0 +1,6 @@
+--- !Up /async
+insert into accounts (name) values ('alice');
+insert into missing_table (name) values ('bob');
+
+--- !Down
+delete from accounts;
@@ -0,0 +1,5 @@
+--- !Up
+create table roles (id serial primary key, name varchar(64));
+
+--- !Down
+drop table roles;
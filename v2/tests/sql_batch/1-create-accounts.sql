@@ -0,0 +1,5 @@
+--- !Up
+create table accounts (id serial primary key, name varchar(64));
+
+--- !Down
+drop table accounts;
@@ -0,0 +1,5 @@
+--- !Up
+alter table accounts add column phone varchar(64);
+
+--- !Down
+alter table accounts drop column phone;
@@ -0,0 +1,5 @@
+--- !Up
+select 1;
+
+--- !Down
+select 1;
@@ -0,0 +1,2 @@
+create table if not exists callbacks (name varchar(64));
+insert into callbacks (name) values ('afterMigrate');
@@ -0,0 +1 @@
+set local lock_timeout = '5s';
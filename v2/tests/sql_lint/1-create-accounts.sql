@@ -0,0 +1,6 @@
+--- !Up
+create table accounts (id serial primary key, name varchar(64));
+create index idx_accounts_name on accounts (name);
+
+--- !Down
+drop table accounts;
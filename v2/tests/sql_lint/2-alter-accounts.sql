@@ -0,0 +1,7 @@
+--- !Up
+alter table accounts add column email varchar(256) not null,
+    add column active boolean not null default true;
+alter table accounts alter column name type text;
+alter table accounts drop column legacy;
+
+--- !Down
@@ -0,0 +1,3 @@
+create table missing (id serial primary key);
+
+--- !Down /stop
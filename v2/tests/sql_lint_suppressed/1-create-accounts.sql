@@ -0,0 +1,8 @@
+-- lint:file-ignore alter-column-type reviewed
+--- !Up
+-- lint:ignore index-concurrently the table is empty
+create index idx_accounts_name on accounts (name);
+alter table accounts alter column name type text;
+
+--- !Down
+drop index idx_accounts_name;
@@ -0,0 +1,6 @@
+--- !Up
+-- lint:ignore not-null-without-default, alter-column-type the table is empty
+alter table accounts add column status text not null, alter column name type varchar(256);
+
+--- !Down
+alter table accounts drop column status;
@@ -0,0 +1,5 @@
+--- !Up /notx
+create index concurrently idx_accounts_name on accounts (name);
+
+--- !Down /notx
+drop index concurrently idx_accounts_name;
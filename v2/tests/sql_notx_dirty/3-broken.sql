@@ -0,0 +1,5 @@
+--- !Up /notx
+create index concurrently idx_accounts_id_name on accounts (id, name);
+select * from missing_table;
+
+--- !Down
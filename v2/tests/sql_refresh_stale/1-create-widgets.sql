@@ -0,0 +1,5 @@
+--- !Up
+create table widgets (id serial primary key);
+
+--- !Down
+drop tabel widgets;
@@ -0,0 +1,4 @@
+--- !Up
+select 1;
+
+--- !Down
@@ -0,0 +1,5 @@
+--- !Up
+create index idx_accounts_name on accounts (name);
+
+--- !Down
+drop index idx_accounts_name;
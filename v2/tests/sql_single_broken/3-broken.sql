@@ -0,0 +1,4 @@
+--- !Up
+select * from missing_table;
+
+--- !Down
@@ -0,0 +1,5 @@
+--- !Up
+select pg_sleep(10);
+
+--- !Down
+
@@ -0,0 +1,6 @@
+--- !Up
+create table accounts (id serial primary key) tablespace {{.Tablespace}};
+alter table accounts owner to {{.MIGRATIONS_TEST_OWNER}};
+
+--- !Down
+drop table accounts; -- accounts_{{.Tablespace}}
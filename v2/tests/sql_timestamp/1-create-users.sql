@@ -0,0 +1,4 @@
+--- !Up
+
+--- !Down
+
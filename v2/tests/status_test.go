@@ -0,0 +1,98 @@
+package tests_test
+
+import (
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Does the status report where each migration stands?
+func TestStatus(t *testing.T) {
+	defer clean(t)
+
+	if err := migrations.WithRevision(2).Apply(conn); err != nil {
+		t.Fatalf("Unable to apply migrations: %s", err)
+	}
+
+	// Applied migrations whose files are gone
+	for _, stmt := range []string{
+		"insert into migrations.applied (migration) values ('7-gone.sql'), ('8-gone.sql'), ('9-gone.sql')",
+		"insert into migrations.rollbacks (migration, down) values ('8-gone.sql', 'select 1'), ('9-gone.sql', '/stop')",
+		"update migrations.rollbacks set down = 'select 2' where migration = '2-add-email-to-sample.sql'",
+	} {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("Unable to prepare the migrations: %s", err)
+		}
+	}
+
+	statuses, err := migrations.Status(conn, migrations.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Unable to get the migrations status: %s", err)
+	}
+
+	expected := []struct {
+		migration string
+		state     migrations.State
+		drift     bool
+	}{
+		{"1-create-sample.sql", migrations.StateApplied, false},
+		{"2-add-email-to-sample.sql", migrations.StateApplied, true},
+		{"3-sample-data.sql", migrations.StatePending, false},
+		{"7-gone.sql", migrations.StateMissing, false},
+		{"8-gone.sql", migrations.StateRollbackStored, false},
+		{"9-gone.sql", migrations.StateStopped, false},
+	}
+
+	if len(statuses) != len(expected) {
+		t.Fatalf("Expected %d statuses; got %+v", len(expected), statuses)
+	}
+
+	for i, status := range statuses {
+		if status.Migration != expected[i].migration || status.State != expected[i].state {
+			t.Errorf("Expected %s %s; got %s %s", expected[i].migration, expected[i].state, status.Migration, status.State)
+		}
+
+		if status.RollbackDrift != expected[i].drift {
+			t.Errorf("Expected rollback drift %v for %s", expected[i].drift, status.Migration)
+		}
+
+		if status.Modified {
+			t.Errorf("Expected %s to be unmodified", status.Migration)
+		}
+	}
+}
+
+// Does the status read a migrations.applied table from an earlier release without upgrading it?
+func TestStatusEarlierRelease(t *testing.T) {
+	defer clean(t)
+
+	if err := migrations.WithRevision(1).Apply(conn); err != nil {
+		t.Fatalf("Unable to apply migrations: %s", err)
+	}
+
+	// Strip the table back to the columns from an earlier release
+	if _, err := conn.Exec("alter table migrations.applied drop column applied_at, drop column duration_ms, " +
+		"drop column applied_by, drop column actor, drop column app_version, drop column library_version, " +
+		"drop column dirty, drop column batch"); err != nil {
+		t.Fatalf("Unable to drop the metadata columns: %s", err)
+	}
+
+	statuses, err := migrations.Status(conn, migrations.DefaultOptions())
+	if err != nil {
+		t.Fatalf("Unable to get the migrations status: %s", err)
+	}
+
+	if len(statuses) != 3 || statuses[0].State != migrations.StateApplied || statuses[1].State != migrations.StatePending {
+		t.Errorf("Expected the first migration applied and the rest pending; got %+v", statuses)
+	}
+
+	var columns int
+	if err := conn.QueryRow("select count(*) from information_schema.columns " +
+		"where table_schema = 'migrations' and table_name = 'applied'").Scan(&columns); err != nil {
+		t.Fatalf("Unable to count the columns: %s", err)
+	}
+
+	if columns != 2 {
+		t.Errorf("Expected the migrations.applied table to be left alone; got %d columns", columns)
+	}
+}
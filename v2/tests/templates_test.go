@@ -0,0 +1,64 @@
+package tests_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Are the template variables rendered into the migration SQL?
+func TestTemplates(t *testing.T) {
+	t.Setenv("MIGRATIONS_TEST_OWNER", "app_owner")
+
+	dir := "./sql_templates"
+	path := filepath.Join(dir, "1-create-accounts.sql")
+
+	m := migrations.NewMigrator(migrations.WithDirectory(dir).WithVars(map[string]string{"Tablespace": "fast"}))
+
+	up, _, err := m.ReadSQL(path, migrations.Up)
+	if err != nil {
+		t.Fatalf("Unable to render the up SQL: %s", err)
+	}
+
+	if !strings.Contains(string(up), "tablespace fast") || !strings.Contains(string(up), "owner to app_owner") {
+		t.Errorf("Expected the variables to be rendered; got %s", up)
+	}
+
+	// Without templates enabled, the SQL is returned as is
+	raw, _, err := migrations.NewMigrator(migrations.WithDirectory(dir)).ReadSQL(path, migrations.Up)
+	if err != nil {
+		t.Fatalf("Unable to read the up SQL: %s", err)
+	}
+
+	if !strings.Contains(string(raw), "{{.Tablespace}}") {
+		t.Errorf("Expected the template to be left alone; got %s", raw)
+	}
+
+	// Unknown variables are an error
+	if _, _, err := migrations.NewMigrator(migrations.WithDirectory(dir).WithTemplates()).ReadSQL(path, migrations.Up); err == nil {
+		t.Errorf("Expected an error for the unknown Tablespace variable")
+	}
+}
+
+// Is the rendered down SQL stored for the embedded rollbacks?
+func TestTemplatesRollback(t *testing.T) {
+	defer clean(t)
+
+	t.Setenv("MIGRATIONS_TEST_OWNER", "postgres")
+
+	dir := "./sql_templates"
+	if err := migrations.WithDirectory(dir).WithVars(map[string]string{"Tablespace": "pg_default"}).Apply(conn); err != nil {
+		t.Fatalf("Unable to apply the migrations: %s", err)
+	}
+
+	var down string
+	if err := conn.QueryRow("select down from migrations.rollbacks where migration = '1-create-accounts.sql'").Scan(&down); err != nil {
+		t.Fatalf("Unable to get the stored rollback: %s", err)
+	}
+
+	if !strings.Contains(down, "accounts_pg_default") || strings.Contains(down, "{{") {
+		t.Errorf("Expected the rendered down SQL to be stored; got %s", down)
+	}
+}
@@ -0,0 +1,115 @@
+package tests_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Are the migrations applied to each tenant schema, with one tenant's failure isolated from the
+// others?
+func TestApplyTenants(t *testing.T) {
+	defer dropTenants(t, "tenant_a", "tenant_b", "tenant_c")
+
+	// Break tenant_c, so the first migration fails
+	if _, err := conn.Exec("create schema tenant_c"); err != nil {
+		t.Fatalf("Unable to create the tenant_c schema: %s", err)
+	}
+
+	if _, err := conn.Exec("create table tenant_c.users (id serial)"); err != nil {
+		t.Fatalf("Unable to create the tenant_c.users table: %s", err)
+	}
+
+	tenants := migrations.Tenants{
+		Schemas:     []string{"tenant_a", "tenant_c", "tenant_b"},
+		Parallelism: 2,
+	}
+
+	results, err := migrations.WithDirectory("./sql_embedded").ApplyTenants(conn, tenants)
+	if !errors.Is(err, migrations.ErrTenantsFailed) {
+		t.Errorf("Expected the tenant migrations to fail; got %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 tenant results; got %d", len(results))
+	}
+
+	for _, result := range results {
+		if result.Schema == "tenant_c" {
+			if result.Err == nil {
+				t.Errorf("Expected tenant_c to fail")
+			}
+			continue
+		}
+
+		if result.Err != nil || result.Skipped {
+			t.Errorf("Expected %s to be migrated; got %+v", result.Schema, result)
+		}
+
+		for _, table := range []string{"users", "roles", migrations.TenantAppliedTable, migrations.TenantRollbacksTable} {
+			if err := tableExists(result.Schema + "." + table); err != nil {
+				t.Errorf("Expected table %s.%s: %s", result.Schema, table, err)
+			}
+		}
+	}
+
+	if err := tableExists("users"); err == nil {
+		t.Errorf("Expected no users table in the public schema")
+	}
+
+	var path string
+	if err := conn.QueryRow("show search_path").Scan(&path); err != nil {
+		t.Fatalf("Unable to get the search_path: %s", err)
+	}
+
+	if path != `"$user", public` {
+		t.Errorf("Expected the search_path to be reset; got %s", path)
+	}
+}
+
+// Can tenants be discovered by a query, and does fail-fast skip the rest?
+func TestApplyTenantsFailFast(t *testing.T) {
+	defer dropTenants(t, "tenant_a", "tenant_b")
+
+	for _, schema := range []string{"tenant_a", "tenant_b"} {
+		if _, err := conn.Exec("create schema " + schema); err != nil {
+			t.Fatalf("Unable to create the %s schema: %s", schema, err)
+		}
+	}
+
+	if _, err := conn.Exec("create table tenant_a.users (id serial)"); err != nil {
+		t.Fatalf("Unable to create the tenant_a.users table: %s", err)
+	}
+
+	tenants := migrations.Tenants{
+		Query:    "select nspname from pg_namespace where nspname like 'tenant\\_%' order by nspname",
+		FailFast: true,
+	}
+
+	results, err := migrations.WithDirectory("./sql_embedded").ApplyTenants(conn, tenants)
+	if !errors.Is(err, migrations.ErrTenantsFailed) {
+		t.Errorf("Expected the tenant migrations to fail; got %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 tenant results; got %d", len(results))
+	}
+
+	if results[0].Schema != "tenant_a" || results[0].Err == nil {
+		t.Errorf("Expected tenant_a to fail; got %+v", results[0])
+	}
+
+	if results[1].Schema != "tenant_b" || !results[1].Skipped {
+		t.Errorf("Expected tenant_b to be skipped; got %+v", results[1])
+	}
+}
+
+// Drop the tenant schemas created by a test.
+func dropTenants(t *testing.T, schemas ...string) {
+	for _, schema := range schemas {
+		if _, err := conn.Exec("drop schema if exists " + schema + " cascade"); err != nil {
+			t.Errorf("Unable to drop the %s schema: %s", schema, err)
+		}
+	}
+}
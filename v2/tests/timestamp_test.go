@@ -0,0 +1,50 @@
+package tests_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Do timestamp revisions sort after the existing sequential revisions?
+func TestTimestampRevision(t *testing.T) {
+	dir := copyMigrations(t, "./sql_timestamp")
+
+	options := migrations.WithDirectory(dir).WithTimestamps()
+	if err := options.Create("create-acl"); err != nil {
+		t.Fatalf("Unable to create the migration: %s", err)
+	}
+
+	latest := options.LatestRevision()
+	if latest < 20000101000000 {
+		t.Fatalf("Expected a timestamp revision; got %d", latest)
+	}
+
+	// Sequential migrations created afterwards follow the timestamp
+	if err := migrations.Create(dir, "create-groups"); err != nil {
+		t.Fatalf("Unable to create the migration: %s", err)
+	}
+
+	if revision := options.LatestRevision(); revision != latest+1 {
+		t.Errorf("Expected revision %d; got %d", latest+1, revision)
+	}
+
+	available, err := options.Available(migrations.Up)
+	if err != nil {
+		t.Fatalf("Unable to list the migrations: %s", err)
+	}
+
+	if len(available) != 4 || available[0] != "1-create-users.sql" || available[1] != "2-create-roles.sql" ||
+		!strings.HasSuffix(available[2], "-create-acl.sql") || !strings.HasSuffix(available[3], "-create-groups.sql") {
+		t.Errorf("Unexpected migration order: %v", available)
+	}
+
+	down := append([]string(nil), available...)
+	sort.Sort(migrations.SortDown(down))
+
+	if down[0] != available[3] || down[3] != "1-create-users.sql" {
+		t.Errorf("Unexpected rollback order: %v", down)
+	}
+}
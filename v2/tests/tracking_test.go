@@ -0,0 +1,70 @@
+package tests_test
+
+import (
+	"testing"
+
+	"github.com/sbowman/migrations/v2"
+)
+
+// Are identifiers quoted safely?
+func TestQuoteIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"migrations":       `"migrations"`,
+		"AppTwo":           `"AppTwo"`,
+		`bad"; drop table`: `"bad""; drop table"`,
+	}
+
+	for name, expected := range tests {
+		if quoted := migrations.QuoteIdentifier(name); quoted != expected {
+			t.Errorf("Expected %s to be quoted as %s; got %s", name, expected, quoted)
+		}
+	}
+}
+
+// Can two applications keep track of their migrations separately in the same database?
+func TestTrackingSchema(t *testing.T) {
+	defer clean(t)
+	defer func() {
+		if _, err := conn.Exec(`drop schema if exists "AppTwo" cascade`); err != nil {
+			t.Errorf("Unable to drop the AppTwo schema: %s", err)
+		}
+	}()
+
+	if err := migrations.WithRevision(1).Apply(conn); err != nil {
+		t.Fatalf("Unable to apply migrations: %s", err)
+	}
+
+	options := migrations.WithDirectory("./sql_embedded").
+		WithTrackingSchema("AppTwo").
+		WithTrackingTables("Applied", "Rollbacks")
+
+	if err := options.Apply(conn); err != nil {
+		t.Fatalf("Unable to apply migrations to the AppTwo schema: %s", err)
+	}
+
+	if err := tableExists("AppTwo.Applied"); err != nil {
+		t.Errorf("Expected the AppTwo.Applied table: %s", err)
+	}
+
+	if err := tableExists("AppTwo.Rollbacks"); err != nil {
+		t.Errorf("Expected the AppTwo.Rollbacks table: %s", err)
+	}
+
+	applied, err := migrations.NewMigrator(options).Applied(conn)
+	if err != nil {
+		t.Fatalf("Unable to get the AppTwo migrations: %s", err)
+	}
+
+	if int64(len(applied)) != migrations.WithDirectory("./sql_embedded").LatestRevision() {
+		t.Errorf("Expected all the embedded migrations in AppTwo; got %v", applied)
+	}
+
+	applied, err = migrations.Applied(conn)
+	if err != nil {
+		t.Fatalf("Unable to get the migrations: %s", err)
+	}
+
+	if len(applied) != 1 {
+		t.Errorf("Expected only the first migration in the migrations schema; got %v", applied)
+	}
+}
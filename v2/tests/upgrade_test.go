@@ -1,6 +1,7 @@
 package tests_test
 
 import (
+	"database/sql"
 	"os"
 	"testing"
 
@@ -215,6 +216,10 @@ func TestDowngrade(t *testing.T) {
 		t.Error("Migrations applied table not found in database")
 	}
 
+	if err := tableExists("migrations.history"); err != nil {
+		t.Error("Migrations history table should be left in place")
+	}
+
 	if err := tableExists("schema_migrations"); err != nil {
 		t.Fatal("The schema_migrations table wasn't created")
 	}
@@ -266,3 +271,54 @@ func TestDowngrade(t *testing.T) {
 	}
 
 }
+
+// Once the migrations tables are upgraded, can a role that doesn't own them apply migrations?
+func TestApplyNonOwner(t *testing.T) {
+	defer clean(t)
+
+	if err := v2.InitializeDB(conn, "./sql"); err != nil {
+		t.Fatalf("Unable to initialize the database: %s", err)
+	}
+
+	if _, err := conn.Exec("create role migrations_nonowner login"); err != nil {
+		t.Fatalf("Unable to create the migrations_nonowner role: %s", err)
+	}
+	defer func() {
+		if _, err := conn.Exec("drop owned by migrations_nonowner"); err != nil {
+			t.Errorf("Unable to drop the objects owned by migrations_nonowner: %s", err)
+		}
+
+		if _, err := conn.Exec("drop role migrations_nonowner"); err != nil {
+			t.Errorf("Unable to drop the migrations_nonowner role: %s", err)
+		}
+	}()
+
+	grants := []string{
+		"grant usage on schema migrations to migrations_nonowner",
+		"grant select, insert, update, delete on all tables in schema migrations to migrations_nonowner",
+		"grant usage, select on all sequences in schema migrations to migrations_nonowner",
+		"grant usage, create on schema public to migrations_nonowner",
+	}
+
+	for _, grant := range grants {
+		if _, err := conn.Exec(grant); err != nil {
+			t.Fatalf("Unable to %s: %s", grant, err)
+		}
+	}
+
+	nonowner, err := sql.Open("pgx", "postgres://migrations_nonowner@localhost/migrations_test?sslmode=disable")
+	if err != nil {
+		t.Fatalf("Unable to connect as migrations_nonowner: %s", err)
+	}
+	defer func() {
+		_ = nonowner.Close()
+	}()
+
+	if err := v2.WithDirectory("./sql").Apply(nonowner); err != nil {
+		t.Fatalf("Unable to apply migrations as migrations_nonowner: %s", err)
+	}
+
+	if err := migrationApplied("3-sample-data.sql"); err != nil {
+		t.Errorf("Expected 3-sample-data.sql to be applied: %s", err)
+	}
+}
@@ -0,0 +1,106 @@
+package migrations
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	// DefaultTrackingSchema is the schema holding the tables that track the migrations.
+	DefaultTrackingSchema = "migrations"
+
+	// DefaultAppliedTable is the table recording the applied migrations.
+	DefaultAppliedTable = "applied"
+
+	// DefaultRollbacksTable is the table storing the "down" SQL for the applied migrations.
+	DefaultRollbacksTable = "rollbacks"
+)
+
+// QuoteIdentifier quotes a PostgreSQL identifier, such as a schema or table name, so it may be
+// safely included in a SQL statement.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Returns the name of the schema tracking the migrations.
+func (m *Migrator) schemaName() string {
+	if m.options.TrackingSchema == "" {
+		return DefaultTrackingSchema
+	}
+
+	return m.options.TrackingSchema
+}
+
+// Returns the name of the table recording the applied migrations.
+func (m *Migrator) appliedName() string {
+	if m.options.AppliedTable == "" {
+		return DefaultAppliedTable
+	}
+
+	return m.options.AppliedTable
+}
+
+// Returns the name of the table storing the rollbacks.
+func (m *Migrator) rollbacksName() string {
+	if m.options.RollbacksTable == "" {
+		return DefaultRollbacksTable
+	}
+
+	return m.options.RollbacksTable
+}
+
+// Returns the name of the table recording the state of the asynchronous migrations, named after
+// the applied table, e.g. "applied_async".
+func (m *Migrator) asyncName() string {
+	return m.appliedName() + "_async"
+}
+
+// Returns the name of the table recording the migrations history:  "history" alongside the default
+// applied table, otherwise named after the applied table, e.g. "migrations_applied_history".
+func (m *Migrator) historyName() string {
+	if m.appliedName() == DefaultAppliedTable {
+		return "history"
+	}
+
+	return m.appliedName() + "_history"
+}
+
+// Returns the quoted tracking schema, for use in SQL statements.
+func (m *Migrator) trackingSchema() string {
+	return QuoteIdentifier(m.schemaName())
+}
+
+// Returns the quoted, schema-qualified applied table, for use in SQL statements.
+func (m *Migrator) appliedTable() string {
+	return m.trackingSchema() + "." + QuoteIdentifier(m.appliedName())
+}
+
+// Returns the quoted, schema-qualified rollbacks table, for use in SQL statements.
+func (m *Migrator) rollbacksTable() string {
+	return m.trackingSchema() + "." + QuoteIdentifier(m.rollbacksName())
+}
+
+// Returns the quoted, schema-qualified asynchronous migrations table, for use in SQL statements.
+func (m *Migrator) asyncTable() string {
+	return m.trackingSchema() + "." + QuoteIdentifier(m.asyncName())
+}
+
+// Returns the quoted, schema-qualified history table, for use in SQL statements.
+func (m *Migrator) historyTable() string {
+	return m.trackingSchema() + "." + QuoteIdentifier(m.historyName())
+}
+
+// Returns true if the table is missing from the tracking schema.
+func (m *Migrator) missingTable(ctx context.Context, conn QueryableContext, table string) bool {
+	row := conn.QueryRowContext(ctx, "select not(exists(select 1 from pg_catalog.pg_class c "+
+		"join pg_catalog.pg_namespace n "+
+		"on n.oid = c.relnamespace "+
+		"where n.nspname = $1 and c.relname = $2))", m.schemaName(), table)
+
+	var result bool
+	if err := row.Scan(&result); err != nil {
+		return true
+	}
+
+	return result
+}
@@ -1,29 +1,34 @@
 package migrations
 
 import (
+	"context"
 	"database/sql"
 )
 
 // Upgrade from migrations/v1 to migrations/v2.  If the database is new or has already been upgraded
 // (the schema_migrations table is missing), does nothing.
 func Upgrade(tx *sql.Tx, directory string) error {
-	if MissingSchemaMigrations(tx) {
+	return WithDirectory(directory).migrator().upgrade(context.Background(), tx)
+}
+
+func (m *Migrator) upgrade(ctx context.Context, tx *sql.Tx) error {
+	if missingSchemaMigrations(ctx, tx) {
 		return nil
 	}
 
 	// Migrate from schema_migrations to the migrations.applied table
-	if err := CopyMigrations(tx); err != nil {
+	if err := m.copyMigrations(ctx, tx); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
 	// Add the rollbacks migrations.rollbacks table
-	if err := UpdateRollbacks(tx, directory); err != nil {
+	if err := m.updateRollbacks(ctx, tx); err != nil {
 		return err
 	}
 
 	// Remove the remainder of migrations/v1
-	if err := dropSchemaMigrations(tx); err != nil {
+	if err := dropSchemaMigrations(ctx, tx); err != nil {
 		return err
 	}
 
@@ -32,29 +37,50 @@ func Upgrade(tx *sql.Tx, directory string) error {
 
 // Downgrade rolls your database back from migrations/v2 to a migrations/v1-compatible
 // database, or specifically, recreate schema_migrations and copy migrations.applied into the
-// schema_migrations table and drop the "migrations" schema.
+// schema_migrations table and drop the other "migrations" tables.  The append-only
+// migrations.history table is left in place, along with the "migrations" schema; drop them by
+// hand once the history is no longer needed.
 func Downgrade(db *sql.DB) error {
-	tx, err := db.Begin()
+	return DowngradeContext(context.Background(), db)
+}
+
+// DowngradeContext rolls your database back to a migrations/v1-compatible database, as with
+// Downgrade.
+func DowngradeContext(ctx context.Context, db *sql.DB) error {
+	return std().DowngradeContext(ctx, db)
+}
+
+// Downgrade rolls your database back to a migrations/v1-compatible database, copying the
+// migrator's applied table into schema_migrations and dropping its tracking tables, except for
+// the history table.  The tracking schema is dropped if nothing else remains in it.
+func (m *Migrator) Downgrade(db Executor) error {
+	return m.DowngradeContext(context.Background(), db)
+}
+
+// DowngradeContext rolls your database back to a migrations/v1-compatible database, as with
+// Downgrade.
+func (m *Migrator) DowngradeContext(ctx context.Context, db Executor) error {
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	if err := CreateSchemaMigrations(tx); err != nil {
+	if err := m.createSchemaMigrations(ctx, tx); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
-	if MissingMigrationsApplied(tx) {
+	if m.missingMigrationsApplied(ctx, tx) {
 		return tx.Commit()
 	}
 
-	if _, err := tx.Exec("insert into schema_migrations (migration) " +
-		"(select migration from migrations.applied) on conflict (migration) do nothing"); err != nil {
+	if _, err := tx.ExecContext(ctx, "insert into schema_migrations (migration) "+
+		"(select migration from "+m.appliedTable()+") on conflict (migration) do nothing"); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
 
-	if err := dropMigrationsSchema(tx); err != nil {
+	if err := m.dropMigrationsSchema(ctx, tx); err != nil {
 		_ = tx.Rollback()
 		return err
 	}
@@ -65,9 +91,13 @@ func Downgrade(db *sql.DB) error {
 // CreateSchemaMigrations creates the schema_migrations table in the database
 // if it doesn't already exist.
 func CreateSchemaMigrations(tx *sql.Tx) error {
-	if MissingSchemaMigrations(tx) {
-		Log.Infof("Creating schema_migrations table in the database")
-		if _, err := tx.Exec("create table schema_migrations(migration varchar(1024) not null primary key)"); err != nil {
+	return std().createSchemaMigrations(context.Background(), tx)
+}
+
+func (m *Migrator) createSchemaMigrations(ctx context.Context, tx *sql.Tx) error {
+	if missingSchemaMigrations(ctx, tx) {
+		m.log.Infof("Creating schema_migrations table in the database")
+		if _, err := tx.ExecContext(ctx, "create table schema_migrations(migration varchar(1024) not null primary key)"); err != nil {
 			return err
 		}
 	}
@@ -76,12 +106,16 @@ func CreateSchemaMigrations(tx *sql.Tx) error {
 }
 
 // MissingSchemaMigrations returns true if there is no schema_migrations table
-// in the database.
+// in the current schema, i.e. the first schema in the search_path.
 func MissingSchemaMigrations(tx *sql.Tx) bool {
-	row := tx.QueryRow("select not(exists(select 1 from pg_catalog.pg_class c " +
-		"join pg_catalog.pg_namespace n " +
-		"on n.oid = c.relnamespace " +
-		"where n.nspname = 'public' and c.relname = 'schema_migrations'))")
+	return missingSchemaMigrations(context.Background(), tx)
+}
+
+func missingSchemaMigrations(ctx context.Context, tx *sql.Tx) bool {
+	row := tx.QueryRowContext(ctx, "select not(exists(select 1 from pg_catalog.pg_class c "+
+		"join pg_catalog.pg_namespace n "+
+		"on n.oid = c.relnamespace "+
+		"where n.nspname = current_schema() and c.relname = 'schema_migrations'))")
 
 	var result bool
 	if err := row.Scan(&result); err != nil {
@@ -94,7 +128,11 @@ func MissingSchemaMigrations(tx *sql.Tx) bool {
 // CopyMigrations copies the migrations from the schema_migrations table to the migrations.applied
 // table.
 func CopyMigrations(tx *sql.Tx) error {
-	if _, err := tx.Exec("insert into migrations.applied(migration) " +
+	return std().copyMigrations(context.Background(), tx)
+}
+
+func (m *Migrator) copyMigrations(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, "insert into "+m.appliedTable()+"(migration) "+
 		"select migration from schema_migrations on conflict (migration) do nothing"); err != nil {
 		return err
 	}
@@ -104,26 +142,44 @@ func CopyMigrations(tx *sql.Tx) error {
 
 // dropSchemaMigrations deletes the migrations/v1 table.  Should only be called from
 // UpgradeMigrations.
-func dropSchemaMigrations(tx *sql.Tx) error {
-	if _, err := tx.Exec("drop table schema_migrations"); err != nil {
+func dropSchemaMigrations(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, "drop table schema_migrations"); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// dropMigrationsSchema deletes the migrations/v2 tables.  Should only be called from
-// DowngradeMigrations.
-func dropMigrationsSchema(tx *sql.Tx) error {
-	if _, err := tx.Exec("drop table migrations.rollbacks"); err != nil {
+// dropMigrationsSchema deletes the migrations/v2 tables, and the tracking schema if no other
+// tables remain in it.  The history table is kept, since it's the only record of what happened.
+// Should only be called from DowngradeMigrations.
+func (m *Migrator) dropMigrationsSchema(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, "drop table "+m.rollbacksTable()); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "drop table if exists "+m.asyncTable()); err != nil {
 		return err
 	}
 
-	if _, err := tx.Exec("drop table migrations.applied"); err != nil {
+	if _, err := tx.ExecContext(ctx, "drop table "+m.appliedTable()); err != nil {
 		return err
 	}
 
-	if _, err := tx.Exec("drop schema migrations"); err != nil {
+	var empty bool
+	row := tx.QueryRowContext(ctx, "select not(exists(select 1 from pg_catalog.pg_class c "+
+		"join pg_catalog.pg_namespace n "+
+		"on n.oid = c.relnamespace "+
+		"where n.nspname = $1))", m.schemaName())
+	if err := row.Scan(&empty); err != nil {
+		return err
+	}
+
+	if !empty {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "drop schema "+m.trackingSchema()); err != nil {
 		return err
 	}
 